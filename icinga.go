@@ -1,36 +1,263 @@
 package main
 
 import (
+  "bufio"
+  "context"
+  "errors"
   "fmt"
   "os"
+  "os/exec"
+  "os/signal"
+  "path/filepath"
+  "reflect"
+  "runtime"
+  "regexp"
+  "sort"
+  "strconv"
+  "strings"
+  "sync"
+  "syscall"
   "time"
   "github.com/jessevdk/go-flags"
+  "encoding/csv"
   "encoding/json"
   "net"
   "net/http"
+  "net/http/httputil"
+  neturl "net/url"
   "bytes"
+  "io"
   "io/ioutil"
+  "math/rand"
 )
 
 type options struct {
   Help         bool      `short:"h" long:"help" description:"show help message"`
+  Version      bool      `long:"version" description:"show version information"`
   Host         string    `long:"host" default:"" description:"Hostname"`
   Timeout      float64   `short:"i" long:"timeout" default:"1.0" description:"Provide the timeout of the Maintenance Mode action as a float in hours.'"`
+  Template     string    `long:"template" description:"Apply a named maintenance template from the config"`
+  Start        string    `long:"start" description:"Start of the maintenance as RFC3339 time or offset from now (e.g. 2h), default now"`
+  Duration     time.Duration `long:"duration" description:"With --update or --refresh, set the end time to now plus this duration"`
+  EndAt        string    `long:"end-at" description:"With --update, end the maintenance early at this RFC3339 time"`
+  StrictComment bool     `long:"strict-comment" description:"Fail instead of truncating comments longer than MaxCommentLength"`
+  Comment      string    `long:"comment" description:"Maintenance comment instead of the automatic one"`
+  Reason       string    `long:"reason" description:"Reason category, one of Reasons from config (default: patching, hardware, network, deploy, incident)"`
+  BuildURL     string    `long:"build-url" description:"CI build URL appended to the comment (default: $CI_JOB_URL, $BUILD_URL or the GitHub Actions run)"`
+  NoComment    bool      `long:"no-comment" description:"Send no comment at all, not even the automatic one"`
+  Diff         bool      `long:"diff" description:"With --update, show field changes (old -> new) before applying"`
+  DryRun       bool      `long:"dry-run" description:"With --update show the changes, with --enable the payload, without applying them"`
+  PreviewTimes bool      `long:"preview-times" description:"Print the computed start, end and window length before submitting"`
+  Cron         string    `long:"cron" description:"Start at the next occurrence of a cron expression (e.g. \"0 2 * * 0\")"`
+  WindowDuration time.Duration `long:"window-duration" description:"Length of the maintenance window, overrides --timeout"`
   Enable       bool      `short:"e" long:"enable" description:"Enable maintenance mode"`
   Disable      bool      `short:"d" long:"disable" description:"Disable maintenance mode"`
   DisableHost  bool      `short:"a" long:"disableall" description:"Disable all maintenances for host"`
-  GetStatus    bool      `short:"g" long:"getstatus" description:"Get maintenance information for host"`
+  Update       bool      `short:"u" long:"update" description:"Update the maintenance given by --id"`
+  Refresh      bool      `long:"refresh" description:"Extend all active maintenances of --host to now plus --duration"`
+  GetStatus    bool      `short:"g" long:"getstatus" description:"Get maintenance information for host, or for --id"`
+  Exists       bool      `long:"exists" description:"Check whether a maintenance exists for --id (HEAD) or --host (exit 0 yes, 1 no)"`
   Silent       bool      `short:"s" long:"silent" description:"Surpress all output"`
-  RPD          int       `long:"rpd" default:"0" decription:"RPD ticket number"`
-  ID           string    `long:"id" description:"Unique ID returned when the maintenance was created"`
+  ChunkSize    int       `long:"chunk-size" default:"50" description:"Split maintenances with more hosts into separate submissions of this size"`
+  MaxHosts     int       `long:"max-hosts" default:"100" description:"Abort bulk operations affecting more hosts/ids than this unless --yes"`
+  NoNormalizeHost bool   `long:"no-normalize-host" description:"Keep host name case as given instead of lowercasing"`
+  OnBadHost    string    `long:"on-bad-host" default:"abort" description:"With several hosts, what to do when some fail DNS [abort|skip|include]"`
+  Verbose      bool      `short:"v" long:"verbose" description:"Show additional diagnostics"`
+  RPD          int       `long:"rpd" default:"0" description:"RPD ticket number, omitted when unset (default: $ICINGA_RPD or $CHANGE_NUMBER)"`
+  NoAllServices bool     `long:"no-allservices" description:"Only put the host check into maintenance, not all services"`
+  ServicePattern string  `long:"service-pattern" description:"Regular expression; only services matching it are put into maintenance"`
+  IDs          []string  `long:"id" description:"Unique ID returned when the maintenance was created (repeatable)"`
+  IDsFile      string    `long:"ids-file" description:"File with maintenance IDs to delete, one per line"`
+  IgnoreMissing bool     `long:"ignore-missing" description:"Treat 404 on --disable/--disableall as success"`
+  FailFast     bool      `long:"fail-fast" description:"Stop bulk operations at the first error"`
+  Continue     bool      `long:"continue" description:"Process all items of bulk operations and report errors at the end (default)"`
+  ProbeEndpoints bool    `long:"probe-endpoints" description:"Send harmless requests to every API endpoint and report which respond as expected"`
+  ValidateOnly bool      `long:"validate-only" description:"Only validate config, arguments, files and host DNS, then report OK or the problems"`
+  PrintConfigSchema bool `long:"print-config-schema" description:"Print an example config with defaults for every supported key, key descriptions on stderr, and exit"`
+  ListOwners   bool      `long:"list-owners" description:"List valid owner names from OwnersEndpoint, or the configured Owners"`
+  Report       bool      `long:"report" description:"Summarize maintenances in a time range (--from/--to)"`
+  ReportFrom   string    `long:"from" default:"-720h" description:"Report range start, RFC3339 or offset from now"`
+  ReportTo     string    `long:"to" description:"Report range end, RFC3339 or offset from now (default: now)"`
   Status       string    `long:"status" default:"active" description:"Status [active|completed|scheduled|deleted]"`
-  ConfigFile   string    `short:"f" long:"file" default:"/etc/fds/icinga.json" description:"Custom config file"`
+  Region       string    `long:"region" description:"Region selecting the BaseURL from Regions in config (default: derived from host)"`
+  StrictHost   bool      `long:"strict-host" description:"Require the host address to reverse-resolve to the host name"`
+  ConnectTimeout time.Duration `long:"connect-timeout" default:"10s" description:"Timeout for connecting (TCP and TLS) to the API"`
+  ReadTimeout  time.Duration `long:"read-timeout" default:"30s" description:"Timeout waiting for API response headers"`
+  HTTPTimeout  time.Duration `long:"http-timeout" default:"5m" description:"Overall timeout per request including the body download"`
+  DNSTimeout   time.Duration `long:"dns-timeout" default:"5s" description:"Timeout for the DNS host check"`
+  ConfigFile   string    `short:"f" long:"file" default:"/etc/fds/icinga.json" description:"Custom config file or http(s) URL"`
+  MinWindow    time.Duration `long:"min-window" description:"Warn and require --yes for windows shorter than this (default: MinWindow from config or 5m)"`
+  SkipValidation bool    `long:"skip-validation" description:"Do not validate the maintenance payload before sending"`
+  CheckOwner   bool      `long:"check-owner" description:"Verify the owners are authorized for the host via OwnershipEndpoint"`
+  Force        bool      `long:"force" description:"Proceed despite failed safety checks"`
+  SkipIfActive bool      `long:"skip-if-active" description:"Do nothing if an active maintenance already covers the requested window"`
+  CheckOverlap bool      `long:"check-overlap" description:"Warn if the new maintenance overlaps an existing active one"`
+  Yes          bool      `short:"y" long:"yes" description:"Assume yes for all confirmation prompts"`
+  Author       string    `long:"author" description:"Person triggering the maintenance, separate from owners (default: $USER)"`
+  OwnerRole    string    `long:"owner-role" description:"Only use configured Owners with this role (e.g. primary, secondary)"`
+  RequireOwner bool      `long:"require-owner" description:"Fail if no Owners are configured instead of using $USER"`
+  SpecFile     string    `long:"spec-file" description:"JSON file with a maintenance spec to submit with --enable (flags override spec fields)"`
+  Batch        bool      `long:"batch" description:"Create all maintenances from --hosts-file or --batch-file in one request"`
+  HostsFile    string    `long:"hosts-file" description:"File with one host per line as host[,hours][,owner;owner]"`
+  BatchFile    string    `long:"batch-file" description:"JSON file with an array of maintenance specs for --batch"`
+  HTTPCache    string    `long:"http-cache" description:"File caching --getstatus responses for conditional requests (ETag/Last-Modified)"`
+  RateLimit    int       `long:"limit-rate-per-host" description:"Refuse more than N enables per host within --rate-window (0 disables, needs id cache)"`
+  RateWindow   time.Duration `long:"rate-window" default:"1h" description:"Window for --limit-rate-per-host"`
+  IDCache      string    `long:"id-cache" description:"File remembering the last created maintenance id per host (default: IDCache from config)"`
+  Retries      int       `long:"retries" default:"0" description:"Retry transient API failures (network errors, 429, 502-504) this many times"`
+  RetryBackoff time.Duration `long:"retry-backoff" default:"1s" description:"Base backoff between retries, doubled per attempt up to 30s"`
+  RetryJitter  string    `long:"retry-jitter" default:"full" description:"Randomize retry backoff [none|full|equal]"`
+  ConnMaxAge   time.Duration `long:"conn-max-age" description:"Drop idle keep-alive connections older than this before the next request (default: keep)"`
+  ReportRetries bool     `long:"report-retries" description:"Print the number of retries and the total retry delay when done"`
+  MaxSkew      time.Duration `long:"max-skew" default:"30s" description:"Warn if the local clock differs more than this from the API server"`
+  AdjustForSkew bool     `long:"adjust-for-skew" description:"Shift computed start/end times by the measured clock skew"`
+  Deadline     string    `long:"deadline" description:"Abort the whole operation after a duration (e.g. 2m) or at an RFC3339 time"`
+  OnSuccess    string    `long:"on-success" description:"Run command after each successful action (env ICINGA_ACTION, ICINGA_HOST, ICINGA_ID, ICINGA_STATUS)"`
+  OnFailure    string    `long:"on-failure" description:"Run command after each failed action (same environment as --on-success)"`
+  Syslog       bool      `long:"syslog" description:"Log every action to syslog (also enabled by Syslog in config)"`
+  SyslogFacility string  `long:"syslog-facility" default:"user" description:"Syslog facility [user|daemon|auth|local0-7]"`
+  SyslogPriority string  `long:"syslog-priority" default:"info" description:"Syslog priority of successful actions [info|notice|warning], failures use err"`
+  Webhook      string    `long:"webhook" description:"URL notified after successful enable, update, refresh and disable (default: WebhookURL from config)"`
+  DumpHTTP     string    `long:"dump-http" description:"Append raw HTTP requests and responses to FILE (API key redacted)"`
+  ShowURL      bool      `long:"show-url" description:"Print the effective request URL to stderr"`
+  Output       string    `short:"o" long:"output" default:"text" description:"Output format [text|json|jsonl|csv|events], jsonl of --getstatus is streamed, events prints one NDJSON line per action"`
+  JSONIndent   int       `long:"json-indent" default:"-1" description:"Indentation of json output, 0 for compact (default: 2 on a terminal, compact when piped)"`
+  Redact       bool      `long:"redact" description:"Mask sensitive fields in --getstatus output"`
+  RedactFields string    `long:"redact-fields" default:"hosts,comment" description:"Fields masked by --redact [hosts,comment,createdBy,updatedBy,author]"`
+  Color        string    `long:"color" default:"auto" description:"Colorize status in text output [auto|always|never]"`
+  DisplayTZ    string    `long:"display-timezone" description:"Show times in text output in this IANA zone (e.g. Europe/Berlin)"`
+  CSVBOM       bool      `long:"csv-bom" description:"Start --output csv with a UTF-8 byte order mark (for Excel)"`
+  CSVDelimiter string    `long:"csv-delimiter" default:"," description:"Field delimiter of --output csv, a single character or 'tab'"`
+  OutputDir    string    `long:"output-dir" description:"With --getstatus, write each host's maintenances to <dir>/<host> instead of stdout"`
+  Compact      bool      `long:"compact" description:"Print one line per maintenance: <id> <host> <status> <end>"`
+  OnlyExpired  bool      `long:"only-expired" description:"Only show active maintenances whose end time has passed"`
+  OnlyMine     bool      `long:"only-mine" description:"Only show maintenances created by the configured owners or $USER"`
+  CreatedBy    string    `long:"created-by" description:"Only show maintenances created by NAME"`
+  HostContains string    `long:"host-contains" description:"List maintenances of all hosts matching SUBSTR or glob (case-insensitive)"`
+  MergeAdjacent bool     `long:"merge-adjacent" description:"Show back-to-back or overlapping maintenances of a host as one range"`
+  Summary      bool      `long:"output-summary" description:"Print a status summary footer after the text output"`
+  SummaryOnly  bool      `long:"summary-only" description:"Only print the status summary"`
+  FirstMatch   bool      `long:"first-match" description:"Only show the first maintenance (earliest ending unless --sort-by is given)"`
+  SortBy       string    `long:"sort-by" description:"Sort by [start|end|created|host|status] (default: start)"`
+  Reverse      bool      `long:"reverse" description:"Reverse the sort order"`
+  Fields       string    `long:"fields" description:"Comma separated fields to include in json, jsonl or csv output (e.g. id,host,endTime)"`
+  QuietOnEmpty bool      `long:"quiet-on-empty" description:"Print nothing when --getstatus finds no maintenances"`
+  InvertExit   bool      `long:"invert-exit" description:"Swap --getstatus exit codes (found/none)"`
+  ExitFound    int       `long:"exit-found" default:"0" description:"Exit code of --getstatus when maintenances are found"`
+  ExitNone     int       `long:"exit-none" default:"1" description:"Exit code of --getstatus when no maintenances are found"`
+  History      bool      `long:"history" description:"Show change history for each maintenance"`
+  ExtraJSON    string    `long:"extra-json" description:"JSON object merged into the enable payload (generated fields take precedence)"`
 }
 
+// --- build information, set via -ldflags "-X main.version=..." ---
+var version, commit, date string
+
+// --- print effective request urls to stderr (--show-url) ---
+var showURL bool
+
+// --- shared http client, timeouts set from flags in main ---
+var httpClient = http.DefaultClient
+
+// --- cached oauth2 access token ---
+var accessToken string
+var tokenExpiry time.Time
+
+// --- context bounding the whole operation (--deadline) ---
+var opCtx = context.Background()
+
+// --- closed on SIGINT/SIGTERM, bulk loops stop starting new requests ---
+var stopRequested = make(chan struct{})
+
+const stopGrace = 10 * time.Second
+
+// --- clock skew to the API server (server minus local) ---
+var clockSkew time.Duration
+var skewMeasured bool
+var maxSkew time.Duration
+
+// --- retry policy for transient api failures ---
+var retries int
+var retryBackoffBase time.Duration
+var retryJitter string
+var retryRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+var retryStats RETRYSTATS
+
+const maxRetryBackoff = 30 * time.Second
+
+// --- action events to syslog (--syslog), nil when disabled ---
+type eventLogger interface {
+  Success(msg string) error
+  Failure(msg string) error
+}
+
+var eventLog eventLogger
+var emitEvents bool
+
+// --- local commands run after actions (--on-success/--on-failure) ---
+var onSuccess string
+var onFailure string
+
+// --- forced reconnect of the shared client (--conn-max-age) ---
+var connMaxAge time.Duration
+var connSince = time.Now()
+
+// --- raw http dump file (--dump-http) ---
+var dumpHTTPPath string
+var authHeaderName = "Authorization"
+
+// --- conditional request cache, in memory and optionally on disk ---
+var httpCachePath string
+var httpCache = map[string]CACHEENTRY{}
+
 type INI struct {
-  BaseURL      string    `json:"BaseURL"`
-  APIKEY       string    `json:"API-KEY"`
-  Owners       string    `json:"Owners"`
+  BaseURL      string    `json:"BaseURL" doc:"Maintenance api base url"`
+  APIKEY       string    `json:"API-KEY" doc:"Api key sent in the auth header"`
+  Owners       OWNERS    `json:"Owners" doc:"Default owners, \"a@x.com, b@x.com\" or a list of {name, role}"`
+  SearchDomains []string `json:"SearchDomains" doc:"Domains tried for short host names"`
+  IDCache      string    `json:"IDCache" doc:"File caching the last maintenance id per host"`
+  MinWindow    string    `json:"MinWindow" doc:"Shortest allowed maintenance window (Go duration, e.g. 15m)"`
+  DefaultAllServices *bool `json:"DefaultAllServices" doc:"Put all services in maintenance unless a flag says otherwise"`
+  OwnershipEndpoint string `json:"OwnershipEndpoint" doc:"Url listing the owners of a host for --check-owner"`
+  Regions      map[string]string `json:"Regions" doc:"Region name to BaseURL for --region"`
+  CommentPrefix string   `json:"CommentPrefix" doc:"Prefix of the automatic comment, followed by the owners"`
+  WebhookURL   string    `json:"WebhookURL" doc:"Url notified after each enable, update and disable"`
+  TokenURL     string    `json:"TokenURL" doc:"Oauth2 token url, enables bearer auth instead of the api key"`
+  ClientID     string    `json:"ClientID" doc:"Oauth2 client id"`
+  ClientSecret string    `json:"ClientSecret" doc:"Oauth2 client secret"`
+  WebBaseURL   string    `json:"WebBaseURL" doc:"Web ui base url, prints a link to created maintenances"`
+  SkipDNSPatterns []string `json:"SkipDNSPatterns" doc:"Host glob patterns that skip the DNS check"`
+  MaxCommentLength int     `json:"MaxCommentLength" doc:"Comments longer than this are truncated"`
+  Templates    map[string]TEMPLATE `json:"Templates" doc:"Named presets for --template"`
+  Syslog       bool      `json:"Syslog" doc:"Log every action to syslog"`
+  SyslogFacility string  `json:"SyslogFacility" doc:"Syslog facility"`
+  OwnersEndpoint string  `json:"OwnersEndpoint" doc:"Url resolving the default owners"`
+  StatusLabels map[string]string `json:"StatusLabels" doc:"Display label per status"`
+  Reasons      []string  `json:"Reasons" doc:"Allowed values of --reason"`
+  ReasonInComment bool   `json:"ReasonInComment" doc:"Add [reason] in front of the comment"`
+  AuthHeaderName string  `json:"AuthHeaderName" doc:"Header carrying the api key"`
+  AuthHeaderFormat string `json:"AuthHeaderFormat" doc:"Auth header value, exactly one %s for the api key"`
+}
+
+// --- values used for config keys left unset ---
+var defaultAllServices = true
+var iniDefaults = INI{
+  DefaultAllServices: &defaultAllServices,
+  CommentPrefix:      "Automatic maintenance mode set by",
+  MaxCommentLength:   512,
+  SyslogFacility:     "user",
+  AuthHeaderName:     "Authorization",
+  AuthHeaderFormat:   "API-KEY %s",
+}
+
+// --- named maintenance preset, explicit flags win over its fields ---
+type TEMPLATE struct {
+  Duration     string    `json:"Duration"`
+  AllServices  *bool     `json:"AllServices"`
+  ServicePattern string  `json:"ServicePattern"`
+  Comment      string    `json:"Comment"`
+  CommentPrefix string   `json:"CommentPrefix"`
+  RPD          int       `json:"RPD"`
+  RequireRPD   bool      `json:"RequireRPD"`
 }
 
 type DT struct {
@@ -45,8 +272,27 @@ type MAINT struct {
   StartTime    string    `json:"startTime"`
   EndTime      string    `json:"endTime"`
   Owners       []string  `json:"owners"`
-  Comment      string    `json:"comment"`
-  RPD          int       `json:rpd"`
+  Comment      string    `json:"comment,omitempty"`
+  RPD          int       `json:"rpd,omitempty"`
+  ServicePattern string  `json:"servicePattern,omitempty"`
+  Author       string    `json:"author,omitempty"`
+  Reason       string    `json:"reason,omitempty"`
+}
+
+// --- maintenance read from a spec file, allservices nil when not given ---
+type SPEC struct {
+  MAINT
+  AllServices  *bool     `json:"allservices"`
+}
+
+// --- maintenance with allservices defaulted from flags and config ---
+func (s SPEC) Maint(opts options, ini INI) MAINT {
+  maint := s.MAINT
+  maint.AllServices = allServices(opts, ini)
+  if s.AllServices != nil {
+    maint.AllServices = *s.AllServices
+  }
+  return maint
 }
 
 
@@ -65,300 +311,4125 @@ type RESPONSE struct {
   Status          string    `json:"status"`
   Comment         string    `json:"comment"`
   Rpd             int       `json:"rpd"`
+  Author          string    `json:"author,omitempty"`
+  Owners          []string  `json:"owners,omitempty"`
+  ServicePattern  string    `json:"servicePattern,omitempty"`
+  Reason          string    `json:"reason,omitempty"`
+}
+
+// --- outcome of an action, usable without the cli ---
+type Result struct {
+  Action       string
+  IDs          []string
+  Status       int
+  Raw          []byte
+  Maintenances []RESPONSE
+}
+
+type ENABLERESULT struct {
+  Result       string    `json:"result"`
+  ID           string    `json:"id"`
+  Maintenance  RESPONSE  `json:"maintenance"`
+  Retries      *RETRYSTATS `json:"retries,omitempty"`
+}
+
+// --- --output events line, fields are stable ---
+type EVENT struct {
+  TS           string    `json:"ts"`
+  Event        string    `json:"event"`
+  Action       string    `json:"action"`
+  Host         string    `json:"host"`
+  ID           string    `json:"id,omitempty"`
+  Error        string    `json:"error,omitempty"`
+}
+
+// --- one row of the --probe-endpoints matrix ---
+type PROBE struct {
+  Method       string    `json:"method"`
+  URL          string    `json:"url"`
+  Status       int       `json:"status"`
+  Expected     []int     `json:"expected"`
+  OK           bool      `json:"ok"`
+  Error        string    `json:"error,omitempty"`
+}
+
+type RETRYSTATS struct {
+  Attempts     int       `json:"attempts"`
+  Delay        float64   `json:"delaySeconds"`
+}
+
+type HOSTENTRY struct {
+  Host         string
+  Timeout      float64
+  Owners       []string
+}
+
+type CACHE struct {
+  IDs          map[string]string  `json:"ids"`
+  Enables      map[string][]string `json:"enables,omitempty"`
+}
+
+type CACHEENTRY struct {
+  ETag         string    `json:"etag"`
+  LastModified string    `json:"lastModified"`
+  Body         []byte    `json:"body"`
+}
+
+type NOTIFICATION struct {
+  Action       string    `json:"action"`
+  Host         string    `json:"host"`
+  ID           string    `json:"id"`
+  Operator     string    `json:"operator"`
+  Time         string    `json:"time"`
+}
+
+type TOKEN struct {
+  AccessToken  string    `json:"access_token"`
+  ExpiresIn    int       `json:"expires_in"`
+}
+
+// --- config owner, Owners is either "a, b" or [{"name": "a", "role": "primary"}, ...] ---
+type OWNER struct {
+  Name         string    `json:"name"`
+  Role         string    `json:"role"`
+}
+
+type OWNERS []OWNER
+
+func (o *OWNERS) UnmarshalJSON(b []byte) error {
+  var plain string
+  if json.Unmarshal(b, &plain) == nil {
+    *o = nil
+    for _, name := range parseOwners(plain) {
+      *o = append(*o, OWNER{Name: name})
+    }
+    return nil
+  }
+  var list []OWNER
+  if err := json.Unmarshal(b, &list); err != nil {
+    return fmt.Errorf("Owners must be a string or a list of {name, role} - %s", err.Error())
+  }
+  *o = list
+  return nil
+}
+
+type OWNERSHIP struct {
+  Owners       []string  `json:"owners"`
+}
+
+type HOSTCOUNT struct {
+  Host         string    `json:"host"`
+  Count        int       `json:"count"`
+}
+
+type REPORT struct {
+  From         string    `json:"from"`
+  To           string    `json:"to"`
+  Total        int       `json:"total"`
+  ByStatus     map[string]int `json:"byStatus"`
+  TopHosts     []HOSTCOUNT `json:"topHosts"`
+  Hours        float64   `json:"maintenanceHours"`
+}
+
+type HISTORY struct {
+  ChangedBy       string    `json:"changedBy"`
+  ChangeTime      string    `json:"changeTime"`
+  Action          string    `json:"action"`
+  Comment         string    `json:"comment"`
+}
+
+// --- fetch config json over http(s) ---
+func fetchINI(url string) ([]byte, error) {
+  resp, err := httpClient.Get(url)
+  if err != nil {
+    return nil, err
+  }
+  defer resp.Body.Close()
+
+  if resp.StatusCode != http.StatusOK {
+    return nil, fmt.Errorf("unexpected status %s", resp.Status)
+  }
+  return ioutil.ReadAll(resp.Body)
 }
 
 // --- read config json file ---
 func readINI(file string) INI {
-    var ini INI
-  
-  // --- read json ini file ---
-  jsonFile, err := os.Open(file)
-  if err != nil {
-    fmt.Printf("Cannot open config file %s - %s\n", file, err.Error())
-    os.Exit(3)
+  var ini INI
+  var content []byte
+
+  if strings.HasPrefix(file, "http://") || strings.HasPrefix(file, "https://") {
+    // --- fetch json ini from url ---
+    var err error
+    content, err = fetchINI(file)
+    if err != nil {
+      fmt.Printf("Cannot fetch config %s - %s\n", file, err.Error())
+      os.Exit(3)
+    }
+  } else {
+    // --- read json ini file ---
+    jsonFile, err := os.Open(file)
+    if err != nil {
+      fmt.Printf("Cannot open config file %s - %s\n", file, err.Error())
+      os.Exit(3)
+    }
+    defer jsonFile.Close()
+    content, _ = ioutil.ReadAll(jsonFile)
   }
-  defer jsonFile.Close()
-  
-  content, _ := ioutil.ReadAll(jsonFile)
-  err = json.Unmarshal(content, &ini)
+
+  err := json.Unmarshal(content, &ini)
   if err != nil {
     fmt.Printf("Parse json failed - %s\n", err.Error())
     os.Exit(3)
   }
+
+  ini.BaseURL = normalizeBaseURL(ini.BaseURL)
   return ini
 }
 
-// --- check if host is valid (DNS only) ---
-func checkHost(host string) bool {
-  //dnsHost := fmt.Sprintf("%s.factset.com", host)
-  iprecs, err := net.LookupIP(host)
-  
-  if err != nil || len(iprecs) == 0 {
-    return(false)
-  } else {
-    return(true)
+// --- fill unset values with placeholders: "<Field>" strings, one element per list and map ---
+func fillExample(v reflect.Value, name string) {
+  switch v.Kind() {
+  case reflect.String:
+    if v.String() == "" {
+      v.SetString("<" + name + ">")
+    }
+  case reflect.Ptr:
+    if v.IsNil() {
+      v.Set(reflect.New(v.Type().Elem()))
+      fillExample(v.Elem(), name)
+    }
+  case reflect.Slice:
+    v.Set(reflect.MakeSlice(v.Type(), 1, 1))
+    fillExample(v.Index(0), name)
+  case reflect.Map:
+    elem := reflect.New(v.Type().Elem()).Elem()
+    fillExample(elem, name)
+    v.Set(reflect.MakeMap(v.Type()))
+    v.SetMapIndex(reflect.ValueOf("<name>"), elem)
+  case reflect.Struct:
+    for i := 0; i < v.NumField(); i++ {
+      if v.Field(i).CanSet() {
+        fillExample(v.Field(i), v.Type().Field(i).Name)
+      }
+    }
   }
 }
 
-// --- get current start and end times ---
-func getDateTime(timeout float64) DT {
-  ts := time.Now()
-  te := ts.Add(time.Second * time.Duration(timeout * 3600))
-  dt := DT { 
-    ts.Format(time.RFC3339),
-    te.Format(time.RFC3339),
+// --- exactly one trailing slash on BaseURL ---
+func normalizeBaseURL(url string) string {
+  if url == "" {
+    return url
   }
-  return dt
+  return strings.TrimRight(url, "/") + "/"
 }
 
-// --- enable maintenacse mode ---
-func maint_enable(opts options, ini INI) {
-  // -- check host --
-  if !checkHost(opts.Host) {
-    if !opts.Silent {
-      fmt.Printf("Host: %s not found!\n", opts.Host)
-    }
-    os.Exit(-1)
+// --- pick BaseURL for --region or the region named in the host ---
+func selectRegion(opts options, ini INI) (string, error) {
+  if len(ini.Regions) == 0 && opts.Region == "" {
+    return ini.BaseURL, nil
   }
 
-  dt := getDateTime(opts.Timeout)
-
-  // -- prepare json --
-  maint := MAINT {
-    opts.Host,
-    []string{opts.Host},
-    true,
-    dt.startTime,
-    dt.endTime,
-    []string{ini.Owners},
-    "Automatic maintenance mode set by " + ini.Owners,
-    opts.RPD,
+  region := opts.Region
+  if region == "" {
+    for _, label := range strings.Split(strings.ToLower(opts.Host), ".")[1:] {
+      if _, ok := ini.Regions[label]; ok {
+        region = label
+        break
+      }
+    }
   }
-  
-  e, err := json.Marshal(maint)
-  if err != nil {
-    fmt.Println(err)
-    return
+  if url, ok := ini.Regions[region]; ok {
+    return normalizeBaseURL(url), nil
   }
-  if !opts.Silent {
-    fmt.Println(string(e))
+  if region == "" && ini.BaseURL != "" {
+    return ini.BaseURL, nil
   }
 
-  url  := fmt.Sprintf("%shost", ini.BaseURL)
-  auth := fmt.Sprintf("API-KEY %s", ini.APIKEY)
-  
-  body := bytes.NewReader(e)
-  req, err := http.NewRequest("POST", url, body)
-  if err != nil {
-    panic(err.Error())
+  var known []string
+  for r := range ini.Regions {
+    known = append(known, r)
   }
-  req.Header.Set("Content-Type", "application/json")
-  req.Header.Set("Authorization", auth)
-  resp, err := http.DefaultClient.Do(req)
-  if err != nil {
-    panic(err.Error())
+  sort.Strings(known)
+  if region == "" {
+    return "", fmt.Errorf("no region given or derived from host, known regions: %s", strings.Join(known, ", "))
   }
-  defer resp.Body.Close()    
+  return "", fmt.Errorf("unknown region %q, known regions: %s", region, strings.Join(known, ", "))
+}
 
-  bodyBytes, err := ioutil.ReadAll(resp.Body)
-  
-  if !opts.Silent {
-    fmt.Println(string(bodyBytes))
+// --- join BaseURL and path elements ---
+func apiURL(ini INI, elem ...string) string {
+  url := strings.TrimRight(ini.BaseURL, "/")
+  for _, e := range elem {
+    url += "/" + strings.Trim(e, "/")
   }
-  
-  os.Exit(0)
+  return url
 }
 
-// --- disable (delete) maintenacse mode ---
-func maint_disable(opts options, ini INI) {
-  var str       []byte
+// --- check if host is valid (DNS only) ---
+func checkHost(host string, opts options, ini INI) error {
+  //dnsHost := fmt.Sprintf("%s.factset.com", host)
 
-  // -- verify if maintenence ID provided --
-  if opts.ID == "" {
-    if !opts.Silent {
-      fmt.Println("Maintenance id must be provided for deletion!")
+  // -- known non-resolvable names (aliases, virtual hosts) --
+  for _, pattern := range ini.SkipDNSPatterns {
+    if ok, _ := filepath.Match(pattern, host); ok {
+      if opts.Verbose {
+        fmt.Fprintf(os.Stderr, "Host: %s skips DNS check, matches %q\n", host, pattern)
+      }
+      return nil
     }
-    os.Exit(3)
   }
-    
-  // -- prepare command line for curl --
-  url  := fmt.Sprintf("%s%s", ini.BaseURL, opts.ID)
-  auth := fmt.Sprintf("API-KEY %s", ini.APIKEY)
 
-  // -- excute --
-  body := bytes.NewReader(str)
-  req, err := http.NewRequest("DELETE", url, body)
-  if err != nil {
-    panic(err.Error())
+  ctx, cancel := context.WithTimeout(opCtx, opts.DNSTimeout)
+  defer cancel()
+
+  iprecs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+
+  var dnsErr *net.DNSError
+  if ctx.Err() == context.DeadlineExceeded || (errors.As(err, &dnsErr) && dnsErr.IsTimeout) {
+    return fmt.Errorf("Host: %s DNS lookup timed out after %s!", host, opts.DNSTimeout)
   }
-  req.Header.Set("Content-Type", "application/json")
-  req.Header.Set("Authorization", auth)
-  resp, err := http.DefaultClient.Do(req)
-  if err != nil {
-    panic(err.Error())
+  if err != nil || len(iprecs) == 0 {
+    return fmt.Errorf("Host: %s not found!", host)
   }
-  defer resp.Body.Close()    
-
-  bodyBytes, err := ioutil.ReadAll(resp.Body)
 
-  if !opts.Silent {
-    fmt.Println(string(bodyBytes))
+  // -- forward-confirmed reverse dns --
+  if opts.StrictHost {
+    for _, ip := range iprecs {
+      names, _ := net.DefaultResolver.LookupAddr(ctx, ip.String())
+      for _, name := range names {
+        name = strings.ToLower(strings.TrimSuffix(name, "."))
+        if name == strings.ToLower(host) || strings.HasPrefix(name, strings.ToLower(host) + ".") {
+          return nil
+        }
+      }
+    }
+    return fmt.Errorf("Host: %s failed reverse DNS check, %s does not resolve back to it!", host, iprecs[0].String())
   }
-    
-  os.Exit(0)
+  return nil
 }
 
-// --- disable (delete) all maintenacse for host ---
-func maint_disableHost(opts options, ini INI) {
-  var str       []byte
+// --- check several hosts in parallel, bad ones abort, are skipped or included per --on-bad-host ---
+func checkHosts(hosts []string, opts options, ini INI) ([]string, error) {
+  var good []string
+  var bad  []string
+  var wg   sync.WaitGroup
 
-  // -- verify if provided host is valid (DNS) --
-  if !checkHost(opts.Host) {
-    if !opts.Silent {
-      fmt.Printf("Host: %s not found!\n", opts.Host)
-    }
-    os.Exit(3)
+  errs := make([]error, len(hosts))
+  sem := make(chan struct{}, 16)
+  for i, host := range hosts {
+    wg.Add(1)
+    sem <- struct{}{}
+    go func(i int, host string) {
+      defer wg.Done()
+      errs[i] = checkHost(host, opts, ini)
+      <-sem
+    }(i, host)
   }
-    
-  // -- prepare command line for curl request --
-  url  := fmt.Sprintf("%shost/%s", ini.BaseURL, opts.Host)
-  auth := fmt.Sprintf("API-KEY %s", ini.APIKEY)
+  wg.Wait()
 
-  // -- excute --
-  body := bytes.NewReader(str)
-  req, err := http.NewRequest("DELETE", url, body)
-  if err != nil {
-    panic(err.Error())
+  for i, host := range hosts {
+    err := errs[i]
+    if err == nil {
+      good = append(good, host)
+      continue
+    }
+    switch opts.OnBadHost {
+    case "skip":
+      fmt.Fprintf(os.Stderr, "Warning: skipping %s - %s\n", host, err.Error())
+    case "include":
+      fmt.Fprintf(os.Stderr, "Warning: including %s anyway - %s\n", host, err.Error())
+      good = append(good, host)
+    default:
+      bad = append(bad, err.Error())
+    }
   }
-  req.Header.Set("Content-Type", "application/json")
-  req.Header.Set("Authorization", auth)
-  resp, err := http.DefaultClient.Do(req)
-  if err != nil {
-    panic(err.Error())
+  if len(bad) == 1 {
+    return nil, errors.New(bad[0])
   }
-  defer resp.Body.Close()    
-
-  bodyBytes, err := ioutil.ReadAll(resp.Body)
-
-  if !opts.Silent {
-    fmt.Println(string(bodyBytes))
+  if len(bad) > 1 {
+    return nil, fmt.Errorf("%d hosts failed validation:\n  %s", len(bad), strings.Join(bad, "\n  "))
   }
-  
-  os.Exit(0)
+  return good, nil
 }
 
-// --- get maintenance information for host ---
-func maint_get(opts options, ini INI) {
-  var str       []byte
-  var response  []RESPONSE
-  
-  // -- check host --
-  if !checkHost(opts.Host) {
-    if !opts.Silent {
-      fmt.Printf("Host: %s not found!\n", opts.Host)
+// --- split comma separated owners list ---
+func parseOwners(owners string) []string {
+  var list []string
+  for _, o := range strings.Split(owners, ",") {
+    if o = strings.TrimSpace(o); o != "" {
+      list = append(list, o)
     }
-    os.Exit(3)
   }
+  return list
+}
 
-  // -- prepare url for curl --
-  url  := fmt.Sprintf("%shost/all/%s?status=%s", ini.BaseURL, opts.Host, opts.Status)
-  auth := fmt.Sprintf("API-KEY %s", ini.APIKEY)
-
-  body := bytes.NewReader(str)
-  req, err := http.NewRequest("GET", url, body)
-  if err != nil {
-    panic(err.Error())
+// --- owners from config, fall back to $USER unless required ---
+func effectiveOwners(opts options, ini INI) ([]string, error) {
+  var owners []string
+  for _, o := range ini.Owners {
+    if opts.OwnerRole != "" && !strings.EqualFold(o.Role, opts.OwnerRole) {
+      continue
+    }
+    if name := strings.TrimSpace(o.Name); name != "" {
+      owners = append(owners, name)
+    }
   }
-  req.Header.Set("Content-Type", "application/json")
-  req.Header.Set("Authorization", auth)
-  resp, err := http.DefaultClient.Do(req)
-  if err != nil {
-    panic(err.Error())
+  if len(owners) > 0 {
+    return owners, nil
   }
-  defer resp.Body.Close()    
-
-  // --- parse response ---
-  bodyBytes, _ := ioutil.ReadAll(resp.Body)
-  err = json.Unmarshal(bodyBytes, &response)
-  if err != nil {
-    panic(err.Error())
+  if opts.OwnerRole != "" {
+    return nil, fmt.Errorf("no Owners with role %s configured in %s", opts.OwnerRole, opts.ConfigFile)
   }
-  
-  if !opts.Silent {
-    for i, resp := range response {
-      serv := "false"
-      if resp.AllServices {
-        serv = "true"
-      }
-      fmt.Printf("\n ------------- Maintenance #%d -------------\n", i+1)
-      fmt.Printf("nmaintenanceId: %s\n", resp.MaintenanceId)
-      fmt.Printf("name: %s\n", resp.Name)
-      fmt.Printf("type: %s\n", resp.Type)
-      fmt.Printf("hosts: %s\n", resp.Hosts[0])
-      fmt.Printf("allServices: %s\n", serv)
-      fmt.Printf("startTime: %s\n", resp.StartTime)
-      fmt.Printf("endTime: %s\n", resp.EndTime)
-      fmt.Printf("createdBy: %s\n", resp.CreatedBy)
-      fmt.Printf("creationTime: %s\n", resp.CreationTime)
-      fmt.Printf("updatedBy: %s\n", resp.UpdatedBy)
-      fmt.Printf("updationTime: %s\n", resp.UpdationTime)
-      fmt.Printf("status: %s\n", resp.Status)
-      fmt.Printf("comment: %s\n", resp.Comment)
-      fmt.Printf("rpd: %d\n", resp.Rpd)
-    }
+  if opts.RequireOwner {
+    return nil, fmt.Errorf("no Owners configured in %s", opts.ConfigFile)
   }
-  
-  if len(response) > 0 {
-    os.Exit(0)
-  } else {
-    os.Exit(1)
+  if user := os.Getenv("USER"); user != "" {
+    return []string{user}, nil
   }
+  return nil, fmt.Errorf("no Owners configured and $USER is not set")
 }
 
-func main() {
-  var opts options
-  
-  // --- parse commant line arguments ---
-  p := flags.NewParser(&opts, flags.Default&^flags.HelpFlag)
-  _, err := p.Parse()
+// --- check if stdin is an interactive terminal ---
+func isTerminal(f *os.File) bool {
+  fi, err := f.Stat()
   if err != nil {
-    fmt.Printf("Fail to parse args: %v", err)
-    os.Exit(3)
+    return false
   }
+  return fi.Mode()&os.ModeCharDevice != 0
+}
 
-  if opts.Help {
-    p.WriteHelp(os.Stdout)
-    os.Exit(0)
+// --- qualify short host name with search domains, ask if ambiguous ---
+func resolveHost(host string, opts options, ini INI) string {
+  var candidates []string
+
+  if host == "" || strings.Contains(host, ".") || len(ini.SearchDomains) == 0 {
+    return host
+  }
+  for _, domain := range ini.SearchDomains {
+    fqdn := host + "." + strings.TrimPrefix(domain, ".")
+    if checkHost(fqdn, opts, ini) == nil {
+      candidates = append(candidates, fqdn)
+    }
   }
 
-  // --- get settings from config file ---
-  ini := readINI(opts.ConfigFile)
+  switch {
+  case len(candidates) == 0:
+    return host
+  case len(candidates) == 1:
+    return candidates[0]
+  }
 
-  // --- validate arguments ---
-  if opts.Host == ""  && (opts.Enable || opts.GetStatus || opts.DisableHost) {
-    p.WriteHelp(os.Stdout)
+  // -- ambiguous, pick interactively or fail --
+  if !isTerminal(os.Stdin) {
+    fmt.Fprintf(os.Stderr, "Host: %s is ambiguous, candidates:\n", host)
+    for _, c := range candidates {
+      fmt.Fprintf(os.Stderr, "  %s\n", c)
+    }
     os.Exit(3)
   }
-  if opts.GetStatus && opts.Status != "active" && opts.Status != "completed" && opts.Status != "scheduled" && opts.Status != "deleted" {
-    p.WriteHelp(os.Stdout)
+  fmt.Fprintf(os.Stderr, "Host: %s is ambiguous, choose one:\n", host)
+  for i, c := range candidates {
+    fmt.Fprintf(os.Stderr, "  %d) %s\n", i+1, c)
+  }
+  fmt.Fprintf(os.Stderr, "Selection: ")
+  answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+  n, err := strconv.Atoi(strings.TrimSpace(answer))
+  if err != nil || n < 1 || n > len(candidates) {
+    fmt.Fprintln(os.Stderr, "Invalid selection")
     os.Exit(3)
   }
-    
-  if opts.Enable {
-    maint_enable(opts, ini)
+  return candidates[n-1]
+}
+
+// --- parse --start (or --deadline) as RFC3339 time or offset from now (e.g. 2h) ---
+func parseStart(start string) (time.Time, error) {
+  if start == "" {
+    return time.Now(), nil
   }
-  
-  if opts.Disable {
-    maint_disable(opts, ini)
+  if d, err := time.ParseDuration(start); err == nil {
+    return time.Now().Add(d), nil
+  }
+  t, err := time.Parse(time.RFC3339, start)
+  if err != nil {
+    return t, fmt.Errorf("%q is neither an RFC3339 time nor an offset", start)
   }
+  return t, nil
+}
 
-  if opts.DisableHost {
-    maint_disableHost(opts, ini)
+// --- parse one cron field (*, lists, ranges, steps) into allowed values ---
+func parseCronField(field string, min int, max int) (map[int]bool, error) {
+  values := map[int]bool{}
+  for _, part := range strings.Split(field, ",") {
+    step := 1
+    if i := strings.Index(part, "/"); i >= 0 {
+      n, err := strconv.Atoi(part[i+1:])
+      if err != nil || n < 1 {
+        return nil, fmt.Errorf("invalid step in %q", part)
+      }
+      step = n
+      part = part[:i]
+    }
+
+    lo, hi := min, max
+    if part != "*" {
+      bounds := strings.SplitN(part, "-", 2)
+      var err error
+      if lo, err = strconv.Atoi(bounds[0]); err != nil {
+        return nil, fmt.Errorf("invalid value %q", part)
+      }
+      hi = lo
+      if len(bounds) == 2 {
+        if hi, err = strconv.Atoi(bounds[1]); err != nil {
+          return nil, fmt.Errorf("invalid range %q", part)
+        }
+      } else if step > 1 {
+        hi = max
+      }
+    }
+    if lo < min || hi > max || lo > hi {
+      return nil, fmt.Errorf("%q out of range %d-%d", part, min, max)
+    }
+    for v := lo; v <= hi; v += step {
+      values[v] = true
+    }
+  }
+  return values, nil
+}
+
+// --- next time after from matching a 5 field cron expression ---
+func nextCron(expr string, from time.Time) (time.Time, error) {
+  fields := strings.Fields(expr)
+  if len(fields) != 5 {
+    return time.Time{}, fmt.Errorf("cron expression needs 5 fields, got %d", len(fields))
+  }
+
+  limits := [][2]int{ {0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7} }
+  var sets []map[int]bool
+  for i, f := range fields {
+    set, err := parseCronField(f, limits[i][0], limits[i][1])
+    if err != nil {
+      return time.Time{}, err
+    }
+    sets = append(sets, set)
+  }
+  if sets[4][7] {
+    sets[4][0] = true
+  }
+  domAny, dowAny := fields[2] == "*", fields[4] == "*"
+
+  // -- walk forward, skipping non-matching days and hours, at most 5 years (Feb 29) --
+  t := from.Truncate(time.Minute).Add(time.Minute)
+  for end := t.AddDate(5, 0, 1); t.Before(end); {
+    dom, dow := sets[2][t.Day()], sets[4][int(t.Weekday())]
+    day := (domAny && dowAny) || (domAny && dow) || (dowAny && dom) || (!domAny && !dowAny && (dom || dow))
+    if !sets[3][int(t.Month())] || !day {
+      t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+      continue
+    }
+    if !sets[1][t.Hour()] {
+      t = t.Add(time.Duration(60 - t.Minute()) * time.Minute)
+      continue
+    }
+    if !sets[0][t.Minute()] {
+      t = t.Add(time.Minute)
+      continue
+    }
+    return t, nil
+  }
+  return time.Time{}, fmt.Errorf("no occurrence of %q within 5 years", expr)
+}
+
+// --- get start and end times ---
+func getDateTime(start time.Time, timeout float64) DT {
+  ts := start
+  te := ts.Add(time.Second * time.Duration(timeout * 3600))
+  dt := DT { 
+    ts.Format(time.RFC3339),
+    te.Format(time.RFC3339),
+  }
+  return dt
+}
+
+// --- normalize status filter (case and common synonyms) ---
+func normalizeStatus(status string) string {
+  status = strings.ToLower(strings.TrimSpace(status))
+  switch status {
+  case "done", "finished", "complete", "expired":
+    return "completed"
+  case "running", "current", "enabled":
+    return "active"
+  case "planned", "pending", "future":
+    return "scheduled"
+  case "removed", "cancelled", "canceled":
+    return "deleted"
+  }
+  return status
+}
+
+// --- host contains pattern, or matches it as a glob, ignoring case ---
+func matchHost(host string, pattern string) bool {
+  host, pattern = strings.ToLower(host), strings.ToLower(pattern)
+  if strings.ContainsAny(pattern, "*?[") {
+    ok, _ := filepath.Match(pattern, host)
+    return ok
+  }
+  return strings.Contains(host, pattern)
+}
+
+// --- keep maintenances with a host matching substring or glob ---
+func filterHostContains(response []RESPONSE, pattern string) ([]RESPONSE, int) {
+  filtered := []RESPONSE{}
+  matched := map[string]bool{}
+
+  for _, r := range response {
+    hit := false
+    for _, h := range r.Hosts {
+      if matchHost(h, pattern) {
+        matched[strings.ToLower(h)] = true
+        hit = true
+      }
+    }
+    if hit {
+      filtered = append(filtered, r)
+    }
+  }
+  return filtered, len(matched)
+}
+
+// --- keep active maintenances that should have ended before now ---
+func filterExpired(response []RESPONSE, now time.Time) []RESPONSE {
+  filtered := []RESPONSE{}
+  for _, r := range response {
+    end, err := time.Parse(time.RFC3339, r.EndTime)
+    if err == nil && end.Before(now) && strings.EqualFold(r.Status, "active") {
+      filtered = append(filtered, r)
+    }
+  }
+  return filtered
+}
+
+// --- keep maintenances created by one of names (case-insensitive) ---
+func filterCreatedBy(response []RESPONSE, names []string) []RESPONSE {
+  filtered := []RESPONSE{}
+  for _, r := range response {
+    for _, n := range names {
+      if strings.EqualFold(r.CreatedBy, n) {
+        filtered = append(filtered, r)
+        break
+      }
+    }
+  }
+  return filtered
+}
+
+// --- sort maintenances by start, end, created, host or status ---
+func sortMaintenances(response []RESPONSE, key string, reverse bool) {
+  parse := func(t string) time.Time {
+    ts, _ := time.Parse(time.RFC3339, t)
+    return ts
+  }
+  less := func(a, b RESPONSE) bool {
+    switch key {
+    case "end":
+      return parse(a.EndTime).Before(parse(b.EndTime))
+    case "created":
+      return parse(a.CreationTime).Before(parse(b.CreationTime))
+    case "host":
+      return strings.Join(a.Hosts, ",") < strings.Join(b.Hosts, ",")
+    case "status":
+      return a.Status < b.Status
+    }
+    return parse(a.StartTime).Before(parse(b.StartTime))
+  }
+  sort.SliceStable(response, func(i, j int) bool {
+    if reverse {
+      return less(response[j], response[i])
+    }
+    return less(response[i], response[j])
+  })
+}
+
+// --- short aliases for --fields ---
+var fieldAliases = map[string]string {
+  "id":   "maintenanceId",
+  "host": "hosts",
+}
+
+// --- resolve --fields list against RESPONSE json keys ---
+func parseFields(list string) ([]string, error) {
+  var fields []string
+
+  known := map[string]bool{}
+  t := reflect.TypeOf(RESPONSE{})
+  for i := 0; i < t.NumField(); i++ {
+    known[strings.Split(t.Field(i).Tag.Get("json"), ",")[0]] = true
+  }
+
+  for _, f := range strings.Split(list, ",") {
+    f = strings.TrimSpace(f)
+    if alias, ok := fieldAliases[f]; ok {
+      f = alias
+    }
+    if !known[f] {
+      return nil, fmt.Errorf("unknown field %q", f)
+    }
+    fields = append(fields, f)
+  }
+  return fields, nil
+}
+
+// --- project maintenances to selected fields ---
+func projectFields(response []RESPONSE, fields []string) []map[string]interface{} {
+  projected := []map[string]interface{}{}
+  for _, r := range response {
+    var all map[string]interface{}
+    b, _ := json.Marshal(r)
+    json.Unmarshal(b, &all)
+
+    m := map[string]interface{}{}
+    for _, f := range fields {
+      m[f] = all[f]
+    }
+    projected = append(projected, m)
+  }
+  return projected
+}
+
+// --- mask selected fields for safe sharing ---
+func redactResponses(response []RESPONSE, fields string) []RESPONSE {
+  redacted := []RESPONSE{}
+  for _, r := range response {
+    for _, f := range strings.Split(fields, ",") {
+      switch strings.TrimSpace(f) {
+      case "hosts":
+        hosts := make([]string, len(r.Hosts))
+        for i := range hosts {
+          hosts[i] = "host-***"
+        }
+        r.Hosts = hosts
+        r.Name = "host-***"
+      case "comment":
+        r.Comment = "[redacted]"
+      case "createdBy":
+        r.CreatedBy = "[redacted]"
+      case "updatedBy":
+        r.UpdatedBy = "[redacted]"
+      case "author":
+        r.Author = "[redacted]"
+      }
+    }
+    redacted = append(redacted, r)
+  }
+  return redacted
+}
+
+// --- json for output, --json-indent or 2 on a tty and compact when piped ---
+func marshalOutput(v interface{}, opts options) []byte {
+  indent := opts.JSONIndent
+  if indent < 0 {
+    indent = 0
+    if isTerminal(os.Stdout) {
+      indent = 2
+    }
+  }
+  if indent == 0 {
+    out, _ := json.Marshal(v)
+    return out
+  }
+  out, _ := json.MarshalIndent(v, "", strings.Repeat(" ", indent))
+  return out
+}
+
+// --- ansi colors per maintenance status ---
+var statusColors = map[string]string {
+  "active":    "\033[32m",
+  "scheduled": "\033[33m",
+  "completed": "\033[90m",
+  "deleted":   "\033[31m",
+}
+
+// --- decide if output should be colored (--color, NO_COLOR, tty) ---
+func useColor(mode string) bool {
+  switch mode {
+  case "always":
+    return true
+  case "never":
+    return false
+  }
+  if _, ok := os.LookupEnv("NO_COLOR"); ok {
+    return false
+  }
+  return isTerminal(os.Stdout)
+}
+
+// --- display label for status from StatusLabels, raw value if unmapped ---
+func statusLabel(status string, labels map[string]string) string {
+  if label, ok := labels[strings.ToLower(status)]; ok {
+    return label
+  }
+  return status
+}
+
+// --- colorize status label ---
+func colorStatus(status string, labels map[string]string, color bool) string {
+  label := statusLabel(status, labels)
+  if c, ok := statusColors[strings.ToLower(status)]; ok && color {
+    return c + label + "\033[0m"
+  }
+  return label
+}
+
+// --- reformat RFC3339 time in zone, unchanged if no zone or unparsable ---
+func displayTime(t string, loc *time.Location) string {
+  if loc == nil {
+    return t
+  }
+  ts, err := time.Parse(time.RFC3339, t)
+  if err != nil {
+    return t
+  }
+  return ts.In(loc).Format(time.RFC3339)
+}
+
+// --- coalesce contiguous windows per host, ids joined with ',' ---
+func mergeAdjacent(response []RESPONSE) []RESPONSE {
+  merged := []RESPONSE{}
+  last := map[string]int{}
+
+  sortMaintenances(response, "start", false)
+  for _, r := range response {
+    host := strings.Join(r.Hosts, ",")
+    if i, ok := last[host]; ok {
+      prevEnd, err1 := time.Parse(time.RFC3339, merged[i].EndTime)
+      start, err2 := time.Parse(time.RFC3339, r.StartTime)
+      end, err3 := time.Parse(time.RFC3339, r.EndTime)
+      if err1 == nil && err2 == nil && err3 == nil && !start.After(prevEnd) {
+        merged[i].MaintenanceId += "," + r.MaintenanceId
+        if end.After(prevEnd) {
+          merged[i].EndTime = r.EndTime
+        }
+        continue
+      }
+    }
+    last[host] = len(merged)
+    merged = append(merged, r)
+  }
+  return merged
+}
+
+// --- status tally, e.g. "Total: 5 (3 active, 2 scheduled)" ---
+func summaryLine(response []RESPONSE, labels map[string]string) string {
+  var parts []string
+
+  counts := map[string]int{}
+  var order []string
+  for _, r := range response {
+    if counts[r.Status] == 0 {
+      order = append(order, r.Status)
+    }
+    counts[r.Status]++
+  }
+  for _, status := range order {
+    parts = append(parts, fmt.Sprintf("%d %s", counts[status], statusLabel(status, labels)))
+  }
+  if len(parts) == 0 {
+    return fmt.Sprintf("Total: %d", len(response))
+  }
+  return fmt.Sprintf("Total: %d (%s)", len(response), strings.Join(parts, ", "))
+}
+
+// --- ask user for confirmation (y/N) ---
+func confirm(prompt string) bool {
+  fmt.Fprintf(os.Stderr, "%s [y/N]: ", prompt)
+  answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+  if err != nil {
+    return false
+  }
+  answer = strings.ToLower(strings.TrimSpace(answer))
+  return answer == "y" || answer == "yes"
+}
+
+// --- prepare api request with json and auth headers ---
+func newRequest(method string, url string, payload []byte, ini INI) (*http.Request, error) {
+  if showURL {
+    fmt.Fprintf(os.Stderr, "%s %s\n", method, url)
+  }
+
+  req, err := http.NewRequestWithContext(opCtx, method, url, bytes.NewReader(payload))
+  if err != nil {
+    return nil, err
+  }
+  req.Header.Set("Content-Type", "application/json")
+  if ini.TokenURL != "" {
+    token, err := bearerToken(ini)
+    if err != nil {
+      return nil, err
+    }
+    req.Header.Set(authHeader(ini), "Bearer " + token)
+  } else {
+    format := iniDefaults.AuthHeaderFormat
+    if ini.AuthHeaderFormat != "" {
+      format = ini.AuthHeaderFormat
+    }
+    req.Header.Set(authHeader(ini), fmt.Sprintf(format, ini.APIKEY))
+  }
+  return req, nil
+}
+
+// --- header carrying the api key, AuthHeaderName or Authorization ---
+func authHeader(ini INI) string {
+  if ini.AuthHeaderName != "" {
+    return ini.AuthHeaderName
+  }
+  return iniDefaults.AuthHeaderName
+}
+
+// --- oauth2 client credentials token, refreshed shortly before expiry ---
+func bearerToken(ini INI) (string, error) {
+  var token TOKEN
+
+  if accessToken != "" && time.Now().Before(tokenExpiry) {
+    return accessToken, nil
+  }
+
+  form := neturl.Values{}
+  form.Set("grant_type", "client_credentials")
+  form.Set("client_id", ini.ClientID)
+  form.Set("client_secret", ini.ClientSecret)
+  req, err := http.NewRequestWithContext(opCtx, "POST", ini.TokenURL, strings.NewReader(form.Encode()))
+  if err != nil {
+    return "", err
+  }
+  req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+  resp, err := httpClient.Do(req)
+  if err != nil {
+    return "", err
+  }
+  defer resp.Body.Close()
+
+  bodyBytes, _ := ioutil.ReadAll(resp.Body)
+  if resp.StatusCode != http.StatusOK {
+    return "", fmt.Errorf("token request failed (%s)", resp.Status)
+  }
+  if err := json.Unmarshal(bodyBytes, &token); err != nil || token.AccessToken == "" {
+    return "", fmt.Errorf("token response has no access_token")
+  }
+
+  accessToken = token.AccessToken
+  tokenExpiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second - 30 * time.Second)
+  return accessToken, nil
+}
+
+// --- http client with separate connect, header and overall timeouts ---
+func newHTTPClient(opts options) *http.Client {
+  transport := http.DefaultTransport.(*http.Transport).Clone()
+  transport.DialContext = (&net.Dialer{
+    Timeout:   opts.ConnectTimeout,
+    KeepAlive: 30 * time.Second,
+  }).DialContext
+  transport.TLSHandshakeTimeout = opts.ConnectTimeout
+  transport.ResponseHeaderTimeout = opts.ReadTimeout
+
+  return &http.Client{
+    Transport: transport,
+    Timeout:   opts.HTTPTimeout,
+  }
+}
+
+// --- first signal asks bulk loops to stop, a second one or the grace period exits ---
+func handleSignals() {
+  sigs := make(chan os.Signal, 2)
+  signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+  go func() {
+    <-sigs
+    fmt.Fprintf(os.Stderr, "\nInterrupted, finishing in-flight request (up to %s, interrupt again to abort)\n", stopGrace)
+    close(stopRequested)
+    select {
+    case <-sigs:
+    case <-time.After(stopGrace):
+    }
+    fmt.Fprintln(os.Stderr, "Aborted.")
+    os.Exit(130)
+  }()
+}
+
+// --- true once a stop was requested ---
+func interrupted() bool {
+  select {
+  case <-stopRequested:
+    return true
+  default:
+    return false
+  }
+}
+
+// --- abort with exit 3 once the operation deadline passed ---
+func deadlineExceeded() {
+  fmt.Fprintln(os.Stderr, "operation deadline exceeded")
+  os.Exit(3)
+}
+
+// --- send api request with retries, watching deadline and clock skew ---
+func doRequest(req *http.Request) (*http.Response, error) {
+  refreshConnections()
+  for attempt := 0; ; attempt++ {
+    sent := time.Now()
+    resp, err := sendRequest(req)
+    if err != nil && opCtx.Err() == context.DeadlineExceeded {
+      deadlineExceeded()
+    }
+    if err == nil {
+      noteSkew(resp, sent)
+    }
+    if attempt >= retries || !retryable(req.Method, resp, err) || (req.Body != nil && req.GetBody == nil) {
+      return resp, err
+    }
+
+    // -- drop this attempt and wait before the next one --
+    if err == nil {
+      resp.Body.Close()
+    }
+    wait := retryBackoff(attempt, retryBackoffBase, retryJitter)
+    retryStats.Attempts++
+    retryStats.Delay += wait.Seconds()
+    select {
+    case <-time.After(wait):
+    case <-opCtx.Done():
+      deadlineExceeded()
+    }
+    if req.GetBody != nil {
+      req.Body, _ = req.GetBody()
+    }
+  }
+}
+
+// --- retry summary on stderr for --report-retries ---
+func reportRetries(opts options) {
+  if opts.ReportRetries {
+    fmt.Fprintf(os.Stderr, "Retries: %d, total delay %.1fs\n", retryStats.Attempts, retryStats.Delay)
+  }
+}
+
+// --- network errors and overload responses are worth retrying ---
+func retryable(method string, resp *http.Response, err error) bool {
+  // -- POST is not idempotent, only retry if the server never saw it --
+  if method == "POST" {
+    var opErr *net.OpError
+    if err != nil {
+      return errors.As(err, &opErr) && opErr.Op == "dial"
+    }
+    return resp.StatusCode == http.StatusTooManyRequests
+  }
+  if err != nil {
+    return true
+  }
+  switch resp.StatusCode {
+  case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+    return true
+  }
+  return false
+}
+
+// --- exponential backoff for attempt n, jittered (none, full or equal) ---
+func retryBackoff(attempt int, base time.Duration, jitter string) time.Duration {
+  if base <= 0 {
+    return 0
+  }
+  d := base << uint(attempt)
+  if d > maxRetryBackoff || d <= 0 {
+    d = maxRetryBackoff
+  }
+  switch jitter {
+  case "full":
+    return time.Duration(retryRand.Int63n(int64(d) + 1))
+  case "equal":
+    return d/2 + time.Duration(retryRand.Int63n(int64(d/2) + 1))
+  }
+  return d
+}
+
+// --- close idle connections once they are older than --conn-max-age ---
+func refreshConnections() {
+  if connMaxAge <= 0 || time.Since(connSince) < connMaxAge {
+    return
+  }
+  httpClient.CloseIdleConnections()
+  connSince = time.Now()
+}
+
+// --- send request, dumping it when --dump-http is set ---
+func sendRequest(req *http.Request) (*http.Response, error) {
+  if dumpHTTPPath == "" {
+    return httpClient.Do(req)
+  }
+
+  auth := req.Header.Get(authHeaderName)
+  reqDump, _ := httputil.DumpRequestOut(req, true)
+  resp, err := httpClient.Do(req)
+
+  var respDump []byte
+  if err == nil {
+    respDump, _ = httputil.DumpResponse(resp, true)
+  } else {
+    respDump = []byte("error: " + err.Error() + "\n")
+  }
+  dump := append(append(reqDump, []byte("\n\n")...), respDump...)
+  if auth != "" {
+    dump = bytes.ReplaceAll(dump, []byte(auth), []byte("[REDACTED]"))
+  }
+
+  f, ferr := os.OpenFile(dumpHTTPPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+  if ferr == nil {
+    _, ferr = f.Write(append(dump, []byte("\n\n")...))
+    f.Close()
+  }
+  if ferr != nil {
+    fmt.Fprintf(os.Stderr, "Warning: cannot write http dump %s - %s\n", dumpHTTPPath, ferr.Error())
+  }
+  return resp, err
+}
+
+// --- measure skew from the Date header of the first response ---
+func noteSkew(resp *http.Response, sent time.Time) {
+  if skewMeasured {
+    return
+  }
+  server, err := http.ParseTime(resp.Header.Get("Date"))
+  if err != nil {
+    return
+  }
+  skewMeasured = true
+
+  // -- Date has second precision, compare against the request midpoint --
+  local := sent.Add(time.Since(sent) / 2)
+  clockSkew = server.Sub(local).Round(time.Second)
+  if maxSkew > 0 && (clockSkew > maxSkew || clockSkew < -maxSkew) {
+    fmt.Fprintf(os.Stderr, "Warning: local clock differs from API server by %s\n", -clockSkew)
+  }
+}
+
+// --- make a cheap request to measure clock skew ---
+func measureSkew(ini INI) {
+  var str       []byte
+
+  if skewMeasured {
+    return
+  }
+  req, err := newRequest("HEAD", ini.BaseURL, str, ini)
+  if err != nil {
+    return
+  }
+  resp, err := doRequest(req)
+  if err == nil {
+    resp.Body.Close()
+  }
+}
+
+// --- detect html/text error pages where json is expected ---
+func checkJSONResponse(resp *http.Response, body []byte) error {
+  ctype := strings.ToLower(resp.Header.Get("Content-Type"))
+  head := strings.ToLower(strings.TrimSpace(string(body)))
+  if len(head) > 512 {
+    head = head[:512]
+  }
+  if strings.HasPrefix(ctype, "text/") || strings.HasPrefix(head, "<") || strings.Contains(head, "<html") {
+    return fmt.Errorf("expected JSON from API but got HTML/text (status %d); check BaseURL", resp.StatusCode)
+  }
+  return nil
+}
+
+// --- look up cached response for url (memory first, then disk) ---
+func lookupHTTPCache(url string) (CACHEENTRY, bool) {
+  if entry, ok := httpCache[url]; ok {
+    return entry, true
+  }
+  if httpCachePath == "" {
+    return CACHEENTRY{}, false
+  }
+  content, err := ioutil.ReadFile(httpCachePath)
+  if err != nil {
+    return CACHEENTRY{}, false
+  }
+  json.Unmarshal(content, &httpCache)
+  entry, ok := httpCache[url]
+  return entry, ok
+}
+
+// --- remember response for url ---
+func storeHTTPCache(url string, entry CACHEENTRY) {
+  httpCache[url] = entry
+  if httpCachePath == "" {
+    return
+  }
+  content, err := json.Marshal(httpCache)
+  if err == nil {
+    err = ioutil.WriteFile(httpCachePath, content, 0600)
+  }
+  if err != nil {
+    fmt.Fprintf(os.Stderr, "Warning: cannot write http cache %s - %s\n", httpCachePath, err.Error())
+  }
+}
+
+// --- decode json array of maintenances element by element ---
+func decodeMaintenances(r io.Reader) ([]RESPONSE, error) {
+  var response []RESPONSE
+
+  err := eachMaintenance(r, func(m RESPONSE) {
+    response = append(response, m)
+  })
+  return response, err
+}
+
+// --- decode json array of maintenances one record at a time ---
+func eachMaintenance(r io.Reader, fn func(RESPONSE)) error {
+  dec := json.NewDecoder(r)
+  tok, err := dec.Token()
+  if err != nil {
+    return err
+  }
+  if tok == nil {
+    return nil
+  }
+  if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+    return fmt.Errorf("expected json array")
+  }
+  for dec.More() {
+    var m RESPONSE
+    if err := dec.Decode(&m); err != nil {
+      return err
+    }
+    fn(m)
+  }
+  _, err = dec.Token()
+  return err
+}
+
+// --- print maintenances from url as json lines while they arrive ---
+func streamMaintenances(url string, opts options, ini INI) int {
+  var str       []byte
+
+  req, err := newRequest("GET", url, str, ini)
+  if err != nil {
+    panic(err.Error())
+  }
+  resp, err := doRequest(req)
+  if err != nil {
+    panic(err.Error())
+  }
+  defer resp.Body.Close()
+
+  body := bufio.NewReader(resp.Body)
+  head, _ := body.Peek(512)
+  if err := checkJSONResponse(resp, head); err != nil {
+    fmt.Println(err.Error())
+    os.Exit(3)
+  }
+  if resp.StatusCode != http.StatusOK {
+    fmt.Printf("Request failed (%s)\n", resp.Status)
+    os.Exit(3)
+  }
+
+  count := 0
+  matched := map[string]bool{}
+  out := bufio.NewWriter(os.Stdout)
+  err = eachMaintenance(body, func(m RESPONSE) {
+    if opts.HostContains != "" {
+      hit := false
+      for _, h := range m.Hosts {
+        if matchHost(h, opts.HostContains) {
+          matched[strings.ToLower(h)] = true
+          hit = true
+        }
+      }
+      if !hit {
+        return
+      }
+    }
+    line, _ := json.Marshal(m)
+    out.Write(append(line, '\n'))
+    out.Flush()
+    count++
+  })
+  if err != nil {
+    fmt.Fprintf(os.Stderr, "Warning: %s (%s), printed %d maintenances received so far\n", errTruncated.Error(), err.Error(), count)
+  }
+  if opts.HostContains != "" {
+    fmt.Fprintf(os.Stderr, "%d hosts match %q\n", len(matched), opts.HostContains)
+  }
+  reportRetries(opts)
+  return count
+}
+
+// --- jsonl without options that need the whole list (or body, --http-cache) can be streamed ---
+func streamable(opts options) bool {
+  return opts.Output == "jsonl" && !opts.Silent && opts.OutputDir == "" && opts.Fields == "" && opts.HTTPCache == "" &&
+    opts.CreatedBy == "" && !opts.OnlyMine && !opts.OnlyExpired && !opts.MergeAdjacent && opts.SortBy == "" &&
+    !opts.FirstMatch && !opts.Reverse && !opts.Redact
+}
+
+// --- fetch maintenances for host with given status ---
+func getMaintenances(host string, status string, ini INI) []RESPONSE {
+  return mustMaintenances(fetchMaintenances(apiURL(ini, "host", "all", host) + "?status=" + status, ini))
+}
+
+// --- fetch maintenances of all hosts with given status ---
+func listMaintenances(status string, ini INI) []RESPONSE {
+  return mustMaintenances(fetchMaintenances(apiURL(ini, "all") + "?status=" + status, ini))
+}
+
+// --- cli handling of fetch errors, partial results are kept ---
+func mustMaintenances(response []RESPONSE, status int, err error) []RESPONSE {
+  if status == http.StatusNotModified {
+    fmt.Fprintln(os.Stderr, "unchanged")
+  }
+  if err == nil {
+    return response
+  }
+  if errors.Is(err, errTruncated) {
+    fmt.Fprintf(os.Stderr, "Warning: %s, showing %d maintenances received so far\n", err.Error(), len(response))
+    return response
+  }
+  if status != 0 {
+    fmt.Println(err.Error())
+    os.Exit(3)
+  }
+  panic(err.Error())
+}
+
+var errTruncated = errors.New("response truncated")
+
+// --- fetch and decode maintenance list from url, returns http status ---
+func fetchMaintenances(url string, ini INI) ([]RESPONSE, int, error) {
+  var str       []byte
+  var response  []RESPONSE
+
+  req, err := newRequest("GET", url, str, ini)
+  if err != nil {
+    return nil, 0, err
+  }
+  cached, isCached := lookupHTTPCache(url)
+  if isCached {
+    if cached.ETag != "" {
+      req.Header.Set("If-None-Match", cached.ETag)
+    }
+    if cached.LastModified != "" {
+      req.Header.Set("If-Modified-Since", cached.LastModified)
+    }
+  }
+  resp, err := doRequest(req)
+  if err != nil {
+    return nil, 0, err
+  }
+  defer resp.Body.Close()
+
+  // --- reuse cached body when unchanged ---
+  bodyBytes, readErr := ioutil.ReadAll(resp.Body)
+  if resp.StatusCode == http.StatusNotModified && isCached {
+    json.Unmarshal(cached.Body, &response)
+    return response, resp.StatusCode, nil
+  }
+  etag, modified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+  if resp.StatusCode == http.StatusOK && readErr == nil && (etag != "" || modified != "") {
+    storeHTTPCache(url, CACHEENTRY{ etag, modified, bodyBytes })
+  }
+
+  // --- parse response, keep what arrived if truncated ---
+  if err := checkJSONResponse(resp, bodyBytes); err != nil {
+    return nil, resp.StatusCode, err
+  }
+  response, err = decodeMaintenances(bytes.NewReader(bodyBytes))
+  if err != nil && len(response) > 0 {
+    return response, resp.StatusCode, fmt.Errorf("%w (%s)", errTruncated, err.Error())
+  }
+  return response, resp.StatusCode, err
+}
+
+// --- fetch change history for maintenance, nil if the API has none ---
+func getHistory(id string, ini INI) []HISTORY {
+  var str       []byte
+  var history   []HISTORY
+
+  url  := apiURL(ini, id, "history")
+
+  req, err := newRequest("GET", url, str, ini)
+  if err != nil {
+    return nil
+  }
+  resp, err := doRequest(req)
+  if err != nil {
+    return nil
+  }
+  defer resp.Body.Close()
+
+  if resp.StatusCode != http.StatusOK {
+    return nil
+  }
+  bodyBytes, _ := ioutil.ReadAll(resp.Body)
+  if json.Unmarshal(bodyBytes, &history) != nil {
+    return nil
+  }
+  return history
+}
+
+// --- print history block for a maintenance ---
+func printHistory(w io.Writer, resp RESPONSE, ini INI) {
+  fmt.Fprintf(w, "history:\n")
+  fmt.Fprintf(w, "  %s created by %s\n", resp.CreationTime, resp.CreatedBy)
+  for _, h := range getHistory(resp.MaintenanceId, ini) {
+    fmt.Fprintf(w, "  %s %s by %s: %s\n", h.ChangeTime, h.Action, h.ChangedBy, h.Comment)
+  }
+  if resp.UpdatedBy != "" {
+    fmt.Fprintf(w, "  %s last updated by %s\n", resp.UpdationTime, resp.UpdatedBy)
+  }
+}
+
+// --- refuse suspiciously short windows unless --yes ---
+func checkMinWindow(opts options, ini INI, dt DT) {
+  min := opts.MinWindow
+  if min == 0 && ini.MinWindow != "" {
+    d, err := time.ParseDuration(ini.MinWindow)
+    if err != nil {
+      fmt.Printf("Invalid MinWindow %q in config - %s\n", ini.MinWindow, err.Error())
+      os.Exit(3)
+    }
+    min = d
+  }
+  if min == 0 {
+    min = 5 * time.Minute
+  }
+
+  start, err1 := time.Parse(time.RFC3339, dt.startTime)
+  end, err2 := time.Parse(time.RFC3339, dt.endTime)
+  if err1 != nil || err2 != nil {
+    return
+  }
+  if window := end.Sub(start); window < min {
+    fmt.Fprintf(os.Stderr, "Warning: maintenance window of %s is shorter than %s\n", window, min)
+    if !opts.Yes {
+      fmt.Fprintln(os.Stderr, "Use --yes to create it anyway")
+      os.Exit(3)
+    }
+  }
+}
+
+// --- guard against accidentally broad bulk operations ---
+func checkMaxHosts(opts options, count int, what string) {
+  if opts.MaxHosts <= 0 || count <= opts.MaxHosts {
+    return
+  }
+  fmt.Fprintf(os.Stderr, "Warning: operation affects %d %s, more than --max-hosts %d\n", count, what, opts.MaxHosts)
+  if !opts.Yes {
+    fmt.Fprintln(os.Stderr, "Use --yes or raise --max-hosts to proceed")
+    os.Exit(3)
+  }
+}
+
+// --- fetch authorized owners for host from OwnershipEndpoint ---
+func getOwnership(host string, ini INI) ([]string, error) {
+  var str       []byte
+  var ownership OWNERSHIP
+
+  url := ini.OwnershipEndpoint
+  if strings.Contains(url, "%s") {
+    url = fmt.Sprintf(url, host)
+  } else {
+    url = strings.TrimRight(url, "/") + "/" + host
+  }
+
+  req, err := newRequest("GET", url, str, ini)
+  if err != nil {
+    return nil, err
+  }
+  resp, err := doRequest(req)
+  if err != nil {
+    return nil, err
+  }
+  defer resp.Body.Close()
+
+  bodyBytes, _ := ioutil.ReadAll(resp.Body)
+  if resp.StatusCode != http.StatusOK {
+    return nil, fmt.Errorf("ownership lookup failed (%s)", resp.Status)
+  }
+  if err := json.Unmarshal(bodyBytes, &ownership); err != nil {
+    return nil, err
+  }
+  return ownership.Owners, nil
+}
+
+// --- fetch known owner names, OwnersEndpoint is absolute or relative to BaseURL ---
+func getOwnerList(ini INI) ([]string, error) {
+  var str       []byte
+  var names     []string
+  var ownership OWNERSHIP
+
+  url := ini.OwnersEndpoint
+  if !strings.Contains(url, "://") {
+    url = apiURL(ini, url)
+  }
+  req, err := newRequest("GET", url, str, ini)
+  if err != nil {
+    return nil, err
+  }
+  resp, err := doRequest(req)
+  if err != nil {
+    return nil, err
+  }
+  defer resp.Body.Close()
+
+  bodyBytes, _ := ioutil.ReadAll(resp.Body)
+  if resp.StatusCode != http.StatusOK {
+    return nil, fmt.Errorf("owners lookup failed (%s)", resp.Status)
+  }
+  if json.Unmarshal(bodyBytes, &names) == nil {
+    return names, nil
+  }
+  if err := json.Unmarshal(bodyBytes, &ownership); err != nil {
+    return nil, err
+  }
+  return ownership.Owners, nil
+}
+
+// --- print owner names, one per line ---
+func maint_listOwners(opts options, ini INI) {
+  if ini.OwnersEndpoint == "" {
+    for _, o := range ini.Owners {
+      if o.Role != "" {
+        fmt.Printf("%s (%s)\n", o.Name, o.Role)
+      } else {
+        fmt.Println(o.Name)
+      }
+    }
+    os.Exit(0)
+  }
+
+  names, err := getOwnerList(ini)
+  if err != nil {
+    fmt.Fprintf(os.Stderr, "Cannot list owners - %s\n", err.Error())
+    os.Exit(3)
+  }
+  sort.Strings(names)
+  if opts.Output == "json" {
+    fmt.Println(string(marshalOutput(names, opts)))
+  } else {
+    for _, n := range names {
+      fmt.Println(n)
+    }
+  }
+  os.Exit(0)
+}
+
+// --- abort unless one of our owners is authorized for host ---
+func checkOwner(opts options, ini INI, host string, owners []string) {
+  if ini.OwnershipEndpoint == "" {
+    fmt.Fprintln(os.Stderr, "--check-owner needs OwnershipEndpoint in config")
+    os.Exit(3)
+  }
+
+  allowed, err := getOwnership(host, ini)
+  if err != nil {
+    fmt.Fprintf(os.Stderr, "Cannot verify ownership of %s - %s\n", host, err.Error())
+    if !opts.Force {
+      os.Exit(3)
+    }
+    return
+  }
+  for _, a := range allowed {
+    for _, o := range owners {
+      if strings.EqualFold(a, o) {
+        return
+      }
+    }
+  }
+
+  fmt.Fprintf(os.Stderr, "Owners %s are not authorized for host %s (owned by %s)\n", strings.Join(owners, ", "), host, strings.Join(allowed, ", "))
+  if !opts.Force {
+    fmt.Fprintln(os.Stderr, "Use --force to create the maintenance anyway")
+    os.Exit(3)
+  }
+}
+
+// --- check if new windows overlap active maintenances of any of their hosts ---
+func checkOverlap(opts options, ini INI, maints ...MAINT) {
+  overlaps := 0
+  for _, maint := range maints {
+    start, _ := time.Parse(time.RFC3339, maint.StartTime)
+    end, _ := time.Parse(time.RFC3339, maint.EndTime)
+
+    for _, host := range maint.Hosts {
+      for _, m := range getMaintenances(host, "active", ini) {
+        mStart, err1 := time.Parse(time.RFC3339, m.StartTime)
+        mEnd, err2 := time.Parse(time.RFC3339, m.EndTime)
+        if err1 != nil || err2 != nil {
+          continue
+        }
+        if start.Before(mEnd) && mStart.Before(end) {
+          fmt.Fprintf(os.Stderr, "Warning: %s overlaps existing maintenance %s (%s - %s)\n", host, m.MaintenanceId, m.StartTime, m.EndTime)
+          overlaps++
+        }
+      }
+    }
+  }
+
+  if overlaps == 0 || opts.Yes {
+    return
+  }
+  if !confirm("Create maintenance anyway?") {
+    if !opts.Silent {
+      fmt.Println("Aborted.")
+    }
+    os.Exit(1)
+  }
+}
+
+// --- id of an active maintenance for host covering the whole window, or "" ---
+func coveringMaintenance(host string, dt DT, ini INI) string {
+  start, err1 := time.Parse(time.RFC3339, dt.startTime)
+  end, err2 := time.Parse(time.RFC3339, dt.endTime)
+  if err1 != nil || err2 != nil {
+    return ""
+  }
+  for _, m := range getMaintenances(host, "active", ini) {
+    mStart, err1 := time.Parse(time.RFC3339, m.StartTime)
+    mEnd, err2 := time.Parse(time.RFC3339, m.EndTime)
+    if err1 == nil && err2 == nil && !mStart.After(start) && !mEnd.Before(end) {
+      return m.MaintenanceId
+    }
+  }
+  return ""
+}
+
+// --- parse --extra-json, must be a json object ---
+func parseExtraJSON(extra string) (map[string]interface{}, error) {
+  var fields map[string]interface{}
+  if err := json.Unmarshal([]byte(extra), &fields); err != nil {
+    return nil, fmt.Errorf("extra json must be a json object - %s", err.Error())
+  }
+  if fields == nil {
+    return nil, fmt.Errorf("extra json must be a json object")
+  }
+  return fields, nil
+}
+
+// --- merge extra fields into payload, generated fields win ---
+func mergeExtraJSON(payload []byte, extra string) ([]byte, error) {
+  var merged map[string]interface{}
+
+  fields, err := parseExtraJSON(extra)
+  if err != nil {
+    return nil, err
+  }
+  if err := json.Unmarshal(payload, &merged); err != nil {
+    return nil, err
+  }
+  for k, v := range fields {
+    if _, ok := merged[k]; !ok {
+      merged[k] = v
+    }
+  }
+  return json.Marshal(merged)
+}
+
+// --- effective id cache path, empty if disabled ---
+func cachePath(opts options, ini INI) string {
+  if opts.IDCache != "" {
+    return opts.IDCache
+  }
+  return ini.IDCache
+}
+
+// --- load id cache, empty cache if missing ---
+func loadCache(path string) CACHE {
+  var cache CACHE
+
+  content, err := ioutil.ReadFile(path)
+  if err == nil {
+    json.Unmarshal(content, &cache)
+  }
+  if cache.IDs == nil {
+    cache.IDs = map[string]string{}
+  }
+  if cache.Enables == nil {
+    cache.Enables = map[string][]string{}
+  }
+  return cache
+}
+
+// --- write id cache ---
+func saveCache(path string, cache CACHE) error {
+  content, err := json.MarshalIndent(cache, "", "  ")
+  if err != nil {
+    return err
+  }
+  if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+    return err
+  }
+  return ioutil.WriteFile(path, content, 0600)
+}
+
+// --- fetch single maintenance by id, returns http status ---
+func getMaintenance(id string, ini INI) (RESPONSE, int, error) {
+  var str       []byte
+  var response  RESPONSE
+
+  url  := apiURL(ini, id)
+
+  req, err := newRequest("GET", url, str, ini)
+  if err != nil {
+    return response, 0, err
+  }
+  resp, err := doRequest(req)
+  if err != nil {
+    return response, 0, err
+  }
+  defer resp.Body.Close()
+
+  bodyBytes, _ := ioutil.ReadAll(resp.Body)
+  if resp.StatusCode != http.StatusOK {
+    return response, resp.StatusCode, fmt.Errorf("%s", resp.Status)
+  }
+  if err := checkJSONResponse(resp, bodyBytes); err != nil {
+    return response, resp.StatusCode, err
+  }
+  err = json.Unmarshal(bodyBytes, &response)
+  return response, resp.StatusCode, err
+}
+
+// --- look up cached id for host, prune if it no longer exists ---
+func cachedID(host string, opts options, ini INI) (string, error) {
+  path := cachePath(opts, ini)
+  cache := loadCache(path)
+
+  id, ok := cache.IDs[host]
+  if !ok {
+    return "", fmt.Errorf("no cached maintenance id for host %s", host)
+  }
+  if _, status, _ := getMaintenance(id, ini); status == http.StatusNotFound {
+    delete(cache.IDs, host)
+    saveCache(path, cache)
+    return "", fmt.Errorf("cached maintenance %s for host %s no longer exists, pruned", id, host)
+  }
+  return id, nil
+}
+
+// --- effective allservices (flag, then config, default true) ---
+func allServices(opts options, ini INI) bool {
+  if opts.NoAllServices || opts.ServicePattern != "" {
+    return false
+  }
+  if ini.DefaultAllServices != nil {
+    return *ini.DefaultAllServices
+  }
+  return *iniDefaults.DefaultAllServices
+}
+
+// --- person triggering the maintenance, defaults to $USER ---
+func effectiveAuthor(opts options) string {
+  if opts.Author != "" {
+    return opts.Author
+  }
+  return os.Getenv("USER")
+}
+
+// --- refuse runaway enables for host unless --force ---
+func checkEnableRate(host string, opts options, ini INI) {
+  path := cachePath(opts, ini)
+  if opts.RateLimit <= 0 || path == "" {
+    return
+  }
+
+  recent := 0
+  for _, ts := range loadCache(path).Enables[host] {
+    if t, err := time.Parse(time.RFC3339, ts); err == nil && time.Since(t) < opts.RateWindow {
+      recent++
+    }
+  }
+  if recent >= opts.RateLimit && !opts.Force {
+    fmt.Fprintf(os.Stderr, "Host %s already had %d enables within %s, use --force to enable anyway\n", host, recent, opts.RateWindow)
+    os.Exit(3)
+  }
+}
+
+// --- remember enable time for host, dropping entries outside the window ---
+func recordEnable(cache *CACHE, host string, window time.Duration) {
+  var kept []string
+  for _, ts := range cache.Enables[host] {
+    if t, err := time.Parse(time.RFC3339, ts); err == nil && time.Since(t) < window {
+      kept = append(kept, ts)
+    }
+  }
+  cache.Enables[host] = append(kept, time.Now().Format(time.RFC3339))
+}
+
+// --- --comment or the automatic comment, empty (omitted) with --no-comment ---
+func effectiveComment(owners []string, opts options, ini INI) string {
+  if opts.NoComment {
+    return ""
+  }
+  comment := autoComment(owners, ini)
+  if opts.Comment != "" {
+    comment = opts.Comment
+  }
+  if opts.BuildURL != "" {
+    comment += " (build: " + opts.BuildURL + ")"
+  }
+  return reasonComment(comment, opts, ini)
+}
+
+// --- originating CI build from the usual environment variables ---
+func ciBuildURL() string {
+  for _, env := range []string{"CI_JOB_URL", "BUILD_URL"} {
+    if v := os.Getenv(env); v != "" {
+      return v
+    }
+  }
+  if os.Getenv("GITHUB_RUN_ID") != "" && os.Getenv("GITHUB_REPOSITORY") != "" {
+    server := os.Getenv("GITHUB_SERVER_URL")
+    if server == "" {
+      server = "https://github.com"
+    }
+    return server + "/" + os.Getenv("GITHUB_REPOSITORY") + "/actions/runs/" + os.Getenv("GITHUB_RUN_ID")
+  }
+  return ""
+}
+
+var buildURLPattern = regexp.MustCompile(`\(build: (\S+)\)`)
+
+// --- build url recorded in a comment by --build-url, or "" ---
+func commentBuildURL(comment string) string {
+  if m := buildURLPattern.FindStringSubmatch(comment); m != nil {
+    return m[1]
+  }
+  return ""
+}
+
+// --- default comment, prefix configurable via CommentPrefix ---
+func autoComment(owners []string, ini INI) string {
+  prefix := iniDefaults.CommentPrefix + " "
+  if ini.CommentPrefix != "" {
+    prefix = strings.TrimRight(ini.CommentPrefix, " ") + " "
+  }
+  return prefix + strings.Join(owners, ", ")
+}
+
+// --- fill unset flags and config defaults from a named template ---
+func applyTemplate(name string, opts *options, ini *INI, timeoutSet bool) error {
+  t, ok := ini.Templates[name]
+  if !ok {
+    var names []string
+    for n := range ini.Templates {
+      names = append(names, n)
+    }
+    sort.Strings(names)
+    if len(names) == 0 {
+      return fmt.Errorf("Unknown template %q, no Templates configured in %s", name, opts.ConfigFile)
+    }
+    return fmt.Errorf("Unknown template %q, available: %s", name, strings.Join(names, ", "))
+  }
+
+  if t.Duration != "" && opts.WindowDuration == 0 && !timeoutSet {
+    d, err := time.ParseDuration(t.Duration)
+    if err != nil {
+      return fmt.Errorf("Template %s: invalid Duration %q", name, t.Duration)
+    }
+    opts.WindowDuration = d
+  }
+  if t.AllServices != nil {
+    ini.DefaultAllServices = t.AllServices
+  }
+  if opts.ServicePattern == "" {
+    opts.ServicePattern = t.ServicePattern
+  }
+  if opts.Comment == "" && !opts.NoComment {
+    opts.Comment = t.Comment
+  }
+  if t.CommentPrefix != "" {
+    ini.CommentPrefix = t.CommentPrefix
+  }
+  if opts.RPD == 0 {
+    opts.RPD = t.RPD
+  }
+  if t.RequireRPD && opts.RPD == 0 && (opts.Enable || opts.Update) {
+    return fmt.Errorf("Template %s requires an RPD, use --rpd or $ICINGA_RPD", name)
+  }
+  return nil
+}
+
+// --- build maintenance payload for host ---
+func buildMaint(host string, dt DT, owners []string, opts options, ini INI) MAINT {
+  return MAINT {
+    host,
+    []string{host},
+    allServices(opts, ini),
+    dt.startTime,
+    dt.endTime,
+    owners,
+    effectiveComment(owners, opts, ini),
+    opts.RPD,
+    opts.ServicePattern,
+    effectiveAuthor(opts),
+    reasonField(opts, ini),
+  }
+}
+
+var defaultReasons = []string{"patching", "hardware", "network", "deploy", "incident"}
+
+// --- check --reason against Reasons from config ---
+func validReason(reason string, ini INI) error {
+  allowed := ini.Reasons
+  if len(allowed) == 0 {
+    allowed = defaultReasons
+  }
+  for _, r := range allowed {
+    if strings.EqualFold(r, reason) {
+      return nil
+    }
+  }
+  return fmt.Errorf("Invalid --reason %q, use one of %s", reason, strings.Join(allowed, ", "))
+}
+
+// --- reason payload field, empty when it goes into the comment ---
+func reasonField(opts options, ini INI) string {
+  if ini.ReasonInComment {
+    return ""
+  }
+  return strings.ToLower(opts.Reason)
+}
+
+// --- prefix comment with [reason] when ReasonInComment is set ---
+func reasonComment(comment string, opts options, ini INI) string {
+  if !ini.ReasonInComment || opts.Reason == "" || opts.NoComment {
+    return comment
+  }
+  return "[" + strings.ToLower(opts.Reason) + "] " + comment
+}
+
+// --- comment without a leading "[reason] " tag ---
+func stripReasonTag(comment string) string {
+  if !strings.HasPrefix(comment, "[") {
+    return comment
+  }
+  if i := strings.Index(comment, "] "); i > 0 {
+    return comment[i+2:]
+  }
+  if strings.HasSuffix(comment, "]") && !strings.Contains(comment[1:], "[") {
+    return ""
+  }
+  return comment
+}
+
+// --- lowercase host name unless --no-normalize-host ---
+func normalizeHost(host string, opts options) string {
+  if opts.NoNormalizeHost {
+    return host
+  }
+  return strings.ToLower(host)
+}
+
+// --- normalizeHost for every host ---
+func normalizeHosts(hosts []string, opts options) []string {
+  normalized := make([]string, len(hosts))
+  for i, h := range hosts {
+    normalized[i] = normalizeHost(h, opts)
+  }
+  return normalized
+}
+
+// --- drop duplicate hosts, keeping first occurrence ---
+func dedupeHosts(hosts []string, opts options) []string {
+  var unique []string
+
+  seen := map[string]bool{}
+  for _, h := range hosts {
+    if seen[h] {
+      continue
+    }
+    seen[h] = true
+    unique = append(unique, h)
+  }
+  if removed := len(hosts) - len(unique); removed > 0 && opts.Verbose {
+    fmt.Fprintf(os.Stderr, "Removed %d duplicate hosts\n", removed)
+  }
+  return unique
+}
+
+// --- normalize hosts file entries and drop duplicate hosts, keeping first occurrence ---
+func dedupeEntries(entries []HOSTENTRY, opts options) []HOSTENTRY {
+  var kept []HOSTENTRY
+
+  hosts := make([]string, len(entries))
+  for i := range entries {
+    entries[i].Host = normalizeHost(entries[i].Host, opts)
+    hosts[i] = entries[i].Host
+  }
+  unique := dedupeHosts(hosts, opts)
+  for _, e := range entries {
+    if len(kept) < len(unique) && e.Host == unique[len(kept)] {
+      kept = append(kept, e)
+    }
+  }
+  return kept
+}
+
+// --- read hosts file (host[,timeout hours][,owner;owner] per line, # comments) ---
+func readHostsFile(file string, timeout float64) ([]HOSTENTRY, error) {
+  var hosts []HOSTENTRY
+
+  f, err := os.Open(file)
+  if err != nil {
+    return nil, err
+  }
+  defer f.Close()
+
+  scanner := bufio.NewScanner(f)
+  for n := 1; scanner.Scan(); n++ {
+    line := strings.TrimSpace(scanner.Text())
+    if line == "" || strings.HasPrefix(line, "#") {
+      continue
+    }
+    fields := strings.Split(line, ",")
+    entry := HOSTENTRY{ strings.TrimSpace(fields[0]), timeout, nil }
+    rest := fields[1:]
+
+    // -- numeric second field is the timeout, anything else owners (host,owner) --
+    if len(rest) > 0 {
+      field := strings.TrimSpace(rest[0])
+      if t, perr := strconv.ParseFloat(field, 64); perr == nil {
+        entry.Timeout = t
+        rest = rest[1:]
+      } else if field == "" {
+        rest = rest[1:]
+      }
+    }
+    if len(rest) > 1 {
+      return nil, fmt.Errorf("line %d: expected host[,hours][,owner;owner], got %q", n, line)
+    }
+    if len(rest) == 1 {
+      entry.Owners = parseOwners(strings.Replace(rest[0], ";", ",", -1))
+    }
+    hosts = append(hosts, entry)
+  }
+  return hosts, scanner.Err()
+}
+
+// --- read batch spec file (json array of maintenances), allservices defaults per entry ---
+func readBatchFile(file string, opts options, ini INI) ([]MAINT, error) {
+  var specs []SPEC
+
+  content, err := ioutil.ReadFile(file)
+  if err != nil {
+    return nil, err
+  }
+  if err := json.Unmarshal(content, &specs); err != nil {
+    return nil, err
+  }
+  maints := make([]MAINT, len(specs))
+  for i, spec := range specs {
+    maints[i] = spec.Maint(opts, ini)
+  }
+  return maints, nil
+}
+
+// --- enable maintenance for many hosts in a single batch request ---
+func maint_batch(opts options, ini INI) {
+  var specs     []MAINT
+  var response  []RESPONSE
+
+  // -- default owners, only required for entries without their own --
+  owners, ownersErr := effectiveOwners(opts, ini)
+
+  // -- collect maintenance specs --
+  if opts.BatchFile != "" {
+    var err error
+    specs, err = readBatchFile(opts.BatchFile, opts, ini)
+    if err != nil {
+      if !opts.Silent {
+        fmt.Printf("Cannot read batch file %s - %s\n", opts.BatchFile, err.Error())
+      }
+      os.Exit(3)
+    }
+  }
+  if opts.HostsFile != "" {
+    hosts, err := readHostsFile(opts.HostsFile, opts.Timeout)
+    if err != nil {
+      if !opts.Silent {
+        fmt.Printf("Cannot read hosts file %s - %s\n", opts.HostsFile, err.Error())
+      }
+      os.Exit(3)
+    }
+    start, _ := parseStart(opts.Start)
+    if opts.AdjustForSkew {
+      measureSkew(ini)
+      start = start.Add(clockSkew)
+    }
+    for _, h := range dedupeEntries(hosts, opts) {
+      hostOwners := owners
+      if len(h.Owners) > 0 {
+        hostOwners = h.Owners
+      }
+      specs = append(specs, buildMaint(h.Host, getDateTime(start, h.Timeout), hostOwners, opts, ini))
+    }
+  }
+  if len(specs) == 0 {
+    if !opts.Silent {
+      fmt.Println("Batch mode needs --hosts-file or --batch-file with at least one entry!")
+    }
+    os.Exit(3)
+  }
+
+  // -- every entry needs an owner, its own or the default --
+  var ownerless []string
+  for i := range specs {
+    if len(specs[i].Owners) > 0 {
+      continue
+    }
+    if ownersErr != nil {
+      ownerless = append(ownerless, specs[i].Name)
+      continue
+    }
+    specs[i].Owners = owners
+    if specs[i].Comment == "" {
+      specs[i].Comment = effectiveComment(owners, opts, ini)
+    }
+  }
+  if len(ownerless) > 0 {
+    exitFailed(opts, "batch", strings.Join(ownerless, ","), "", fmt.Errorf("No owner for %s - %s", strings.Join(ownerless, ", "), ownersErr.Error()))
+  }
+
+  // -- check hosts --
+  for i := range specs {
+    specs[i].Hosts = dedupeHosts(normalizeHosts(specs[i].Hosts, opts), opts)
+    specs[i].Name = normalizeHost(specs[i].Name, opts)
+  }
+  var all []string
+  for _, spec := range specs {
+    all = append(all, spec.Hosts...)
+  }
+  valid, err := checkHosts(all, opts, ini)
+  if err != nil {
+    exitFailed(opts, "batch", fmt.Sprintf("%d hosts", len(all)), "", err)
+  }
+  ok := map[string]bool{}
+  for _, h := range valid {
+    ok[h] = true
+  }
+  kept := specs[:0]
+  for _, spec := range specs {
+    var hosts []string
+    for _, h := range spec.Hosts {
+      if ok[h] {
+        hosts = append(hosts, h)
+      }
+    }
+    if len(hosts) > 0 {
+      spec.Hosts = hosts
+      kept = append(kept, spec)
+    }
+  }
+  specs = kept
+  if len(specs) == 0 {
+    if !opts.Silent {
+      fmt.Println("No valid hosts left to submit!")
+    }
+    os.Exit(3)
+  }
+
+  hostCount := 0
+  for _, spec := range specs {
+    hostCount += len(spec.Hosts)
+  }
+  checkMaxHosts(opts, hostCount, "hosts")
+
+  for i := range specs {
+    specs[i].Comment = limitComment(specs[i].Comment, opts, ini)
+    checkMinWindow(opts, ini, DT{ specs[i].StartTime, specs[i].EndTime })
+  }
+  mustValidate(opts, "batch", fmt.Sprintf("%d hosts", hostCount), specs...)
+  if opts.CheckOverlap {
+    checkOverlap(opts, ini, specs...)
+  }
+
+  // -- each entry gets --extra-json like a single enable --
+  entries := make([]json.RawMessage, len(specs))
+  for i := range specs {
+    entry, err := enablePayload(specs[i], opts)
+    if err != nil {
+      fmt.Println(err)
+      os.Exit(3)
+    }
+    entries[i] = entry
+  }
+  e, err := json.Marshal(entries)
+  if err != nil {
+    fmt.Println(err)
+    return
+  }
+  if opts.DryRun {
+    fmt.Println(string(e))
+    os.Exit(0)
+  }
+
+  url  := apiURL(ini, "batch")
+
+  req, err := newRequest("POST", url, e, ini)
+  if err != nil {
+    panic(err.Error())
+  }
+  resp, err := doRequest(req)
+  if err != nil {
+    logEvent("batch", fmt.Sprintf("%d hosts", hostCount), "", err.Error())
+    fmt.Fprintln(os.Stderr, err.Error())
+    os.Exit(3)
+  }
+  defer resp.Body.Close()
+
+  bodyBytes, err := ioutil.ReadAll(resp.Body)
+  reportRetries(opts)
+  if err := checkJSONResponse(resp, bodyBytes); err != nil {
+    fmt.Println(err.Error())
+    os.Exit(3)
+  }
+  if resp.StatusCode < 200 || resp.StatusCode > 299 || json.Unmarshal(bodyBytes, &response) != nil {
+    logEvent("batch", fmt.Sprintf("%d hosts", hostCount), "", resp.Status)
+    if !opts.Silent {
+      fmt.Printf("Batch request failed (%s)\n", resp.Status)
+      fmt.Println(string(bodyBytes))
+    }
+    os.Exit(3)
+  }
+  for _, r := range response {
+    logEvent("enable", strings.Join(r.Hosts, ","), r.MaintenanceId, "ok")
+    notifyWebhook(opts, ini, "enable", strings.Join(r.Hosts, ","), r.MaintenanceId)
+  }
+
+  if !opts.Silent {
+    if opts.Output == "json" {
+      out := marshalOutput(response, opts)
+      fmt.Println(string(out))
+    } else {
+      for _, r := range response {
+        fmt.Printf("Created %s for %s\n", r.MaintenanceId, strings.Join(r.Hosts, ","))
+      }
+    }
+  }
+  os.Exit(0)
+}
+
+// --- read maintenance spec, apply flag overrides and validate ---
+func readSpecFile(opts options, ini INI) (MAINT, error) {
+  var spec SPEC
+
+  content, err := ioutil.ReadFile(opts.SpecFile)
+  if err != nil {
+    return MAINT{}, err
+  }
+  if err := json.Unmarshal(content, &spec); err != nil {
+    return MAINT{}, err
+  }
+  maint := spec.Maint(opts, ini)
+
+  // -- flags win over spec --
+  if opts.Host != "" {
+    maint.Hosts = []string{opts.Host}
+  }
+  if opts.RPD != 0 {
+    maint.RPD = opts.RPD
+  }
+  if opts.ServicePattern != "" {
+    maint.ServicePattern = opts.ServicePattern
+  }
+  if opts.NoAllServices || opts.ServicePattern != "" {
+    maint.AllServices = false
+  }
+  if opts.Author != "" || maint.Author == "" {
+    maint.Author = effectiveAuthor(opts)
+  }
+  if opts.Reason != "" {
+    maint.Reason = reasonField(opts, ini)
+  }
+  if len(maint.Owners) == 0 {
+    maint.Owners, err = effectiveOwners(opts, ini)
+    if err != nil {
+      return maint, err
+    }
+  }
+  maint.Hosts = normalizeHosts(maint.Hosts, opts)
+  if maint.Name == "" && len(maint.Hosts) > 0 {
+    maint.Name = maint.Hosts[0]
+  }
+  maint.Name = normalizeHost(maint.Name, opts)
+  if opts.Comment != "" || opts.NoComment || maint.Comment == "" {
+    maint.Comment = effectiveComment(maint.Owners, opts, ini)
+  }
+
+  // -- required fields --
+  maint.Hosts = dedupeHosts(maint.Hosts, opts)
+  if len(maint.Hosts) == 0 {
+    return maint, fmt.Errorf("spec has no hosts")
+  }
+  return maint, nil
+}
+
+// --- check payload invariants, returns all problems at once ---
+func validateMaint(maint MAINT) []error {
+  var errs []error
+
+  if len(maint.Hosts) == 0 {
+    errs = append(errs, fmt.Errorf("no hosts"))
+  }
+  for _, h := range maint.Hosts {
+    if strings.TrimSpace(h) == "" {
+      errs = append(errs, fmt.Errorf("empty host name"))
+      break
+    }
+  }
+  start, serr := time.Parse(time.RFC3339, maint.StartTime)
+  if serr != nil {
+    errs = append(errs, fmt.Errorf("startTime %q is not RFC3339", maint.StartTime))
+  }
+  end, eerr := time.Parse(time.RFC3339, maint.EndTime)
+  if eerr != nil {
+    errs = append(errs, fmt.Errorf("endTime %q is not RFC3339", maint.EndTime))
+  }
+  if serr == nil && eerr == nil && !start.Before(end) {
+    errs = append(errs, fmt.Errorf("startTime %s is not before endTime %s", maint.StartTime, maint.EndTime))
+  }
+  if len(parseOwners(strings.Join(maint.Owners, ","))) == 0 {
+    errs = append(errs, fmt.Errorf("no owners"))
+  }
+  return errs
+}
+
+// --- truncate over-long comment with an ellipsis, exit with --strict-comment ---
+func limitComment(comment string, opts options, ini INI) string {
+  max := ini.MaxCommentLength
+  if max <= 0 {
+    max = iniDefaults.MaxCommentLength
+  }
+  runes := []rune(comment)
+  if len(runes) <= max {
+    return comment
+  }
+  if opts.StrictComment {
+    fmt.Fprintf(os.Stderr, "Comment is %d characters, maximum is %d\n", len(runes), max)
+    os.Exit(3)
+  }
+  if !opts.Silent {
+    fmt.Fprintf(os.Stderr, "Warning: comment is %d characters, truncating to %d\n", len(runes), max)
+  }
+  return string(runes[:max-1]) + "…"
+}
+
+// --- print validation problems and exit unless --skip-validation ---
+func mustValidate(opts options, action string, host string, maints ...MAINT) {
+  var errs []error
+
+  if opts.SkipValidation {
+    return
+  }
+  for _, m := range maints {
+    for _, err := range validateMaint(m) {
+      errs = append(errs, fmt.Errorf("%s: %s", m.Name, err.Error()))
+    }
+  }
+  if len(errs) == 0 {
+    return
+  }
+  fmt.Fprintln(os.Stderr, "Invalid maintenance:")
+  var msgs []string
+  for _, err := range errs {
+    fmt.Fprintf(os.Stderr, "  - %s\n", err.Error())
+    msgs = append(msgs, err.Error())
+  }
+  logEvent(action, host, "", "invalid maintenance: " + strings.Join(msgs, "; "))
+  os.Exit(3)
+}
+
+// --- marshal payload, merging --extra-json ---
+func enablePayload(maint MAINT, opts options) ([]byte, error) {
+  e, err := json.Marshal(maint)
+  if err != nil {
+    return nil, err
+  }
+  if opts.ExtraJSON != "" {
+    return mergeExtraJSON(e, opts.ExtraJSON)
+  }
+  return e, nil
+}
+
+// --- create maintenance, Status is 0 if the request was not sent ---
+func MaintEnable(maint MAINT, opts options, ini INI) (Result, error) {
+  result := Result{ Action: "enable" }
+
+  e, err := enablePayload(maint, opts)
+  if err != nil {
+    return result, err
+  }
+
+  req, err := newRequest("POST", apiURL(ini, "host"), e, ini)
+  if err != nil {
+    return result, err
+  }
+  resp, err := doRequest(req)
+  if err != nil {
+    return result, err
+  }
+  defer resp.Body.Close()
+
+  result.Status = resp.StatusCode
+  result.Raw, err = ioutil.ReadAll(resp.Body)
+  if err != nil {
+    return result, err
+  }
+  if resp.StatusCode < 200 || resp.StatusCode > 299 {
+    return result, fmt.Errorf("%s", resp.Status)
+  }
+
+  var created RESPONSE
+  if json.Unmarshal(result.Raw, &created) == nil {
+    result.IDs = []string{created.MaintenanceId}
+    result.Maintenances = []RESPONSE{created}
+  }
+  return result, nil
+}
+
+// --- maintenance page in the web ui ---
+func webURL(ini INI, id string) string {
+  return normalizeBaseURL(ini.WebBaseURL) + neturl.PathEscape(id)
+}
+
+// --- show computed window, in --display-timezone if given ---
+func previewTimes(dt DT, opts options) {
+  var loc *time.Location
+  if opts.DisplayTZ != "" {
+    loc, _ = time.LoadLocation(opts.DisplayTZ)
+  }
+  fmt.Fprintf(os.Stderr, "start:  %s\n", displayTime(dt.startTime, loc))
+  fmt.Fprintf(os.Stderr, "end:    %s\n", displayTime(dt.endTime, loc))
+  start, err1 := time.Parse(time.RFC3339, dt.startTime)
+  end, err2 := time.Parse(time.RFC3339, dt.endTime)
+  if err1 == nil && err2 == nil {
+    fmt.Fprintf(os.Stderr, "window: %s\n", end.Sub(start))
+  }
+}
+
+// --- "created" or "updated", from 201 or the record's update time ---
+func enableOutcome(result Result) string {
+  if result.Status == http.StatusCreated || len(result.Maintenances) == 0 {
+    return "created"
+  }
+  m := result.Maintenances[0]
+  if m.UpdationTime != "" && m.UpdationTime != m.CreationTime {
+    return "updated"
+  }
+  return "created"
+}
+
+// --- submit a large host list as several maintenances of --chunk-size hosts ---
+func enableChunks(maint MAINT, opts options, ini INI) {
+  var ids    []string
+  var failed int
+
+  chunks := (len(maint.Hosts) + opts.ChunkSize - 1) / opts.ChunkSize
+  handleSignals()
+  for i := 0; i < chunks; i++ {
+    if interrupted() {
+      break
+    }
+    chunk := maint
+    end := (i + 1) * opts.ChunkSize
+    if end > len(maint.Hosts) {
+      end = len(maint.Hosts)
+    }
+    chunk.Hosts = maint.Hosts[i*opts.ChunkSize:end]
+    hosts := strings.Join(chunk.Hosts, ",")
+
+    result, err := MaintEnable(chunk, opts, ini)
+    if err != nil {
+      failed++
+      logEvent("enable", hosts, "", err.Error())
+      if !opts.Silent {
+        fmt.Printf("Chunk %d/%d (%d hosts) failed - %s\n", i+1, chunks, len(chunk.Hosts), err.Error())
+      }
+      if opts.FailFast {
+        break
+      }
+      continue
+    }
+
+    id := ""
+    if len(result.IDs) > 0 {
+      id = result.IDs[0]
+      ids = append(ids, id)
+    }
+    logEvent("enable", hosts, id, "ok")
+    notifyWebhook(opts, ini, "enable", hosts, id)
+    rememberEnable(chunk.Hosts, id, opts, ini)
+    if !opts.Silent && opts.Output != "json" {
+      fmt.Printf("Chunk %d/%d (%d hosts) created %s\n", i+1, chunks, len(chunk.Hosts), id)
+    }
+  }
+
+  reportRetries(opts)
+  if !opts.Silent {
+    if opts.Output == "json" {
+      fmt.Println(string(marshalOutput(ids, opts)))
+    } else {
+      fmt.Printf("Created %d of %d maintenances, %d failed: %s\n", len(ids), chunks, failed, strings.Join(ids, ","))
+    }
+  }
+  if interrupted() {
+    fmt.Fprintf(os.Stderr, "Interrupted, created: %s\n", strings.Join(ids, ","))
+    os.Exit(130)
+  }
+  if failed > 0 {
+    os.Exit(3)
+  }
+  os.Exit(0)
+}
+
+// --- enable maintenacse mode ---
+func maint_enable(opts options, ini INI) {
+  var maint MAINT
+
+  // -- load spec file --
+  if opts.SpecFile != "" {
+    var err error
+    maint, err = readSpecFile(opts, ini)
+    if err != nil {
+      exitFailed(opts, "enable", opts.Host, "", fmt.Errorf("Invalid spec file %s - %s", opts.SpecFile, err.Error()))
+    }
+    opts.Host = maint.Hosts[0]
+  } else {
+    maint.Hosts = []string{opts.Host}
+  }
+
+  // -- check host --
+  hosts, err := checkHosts(maint.Hosts, opts, ini)
+  if err == nil && len(hosts) == 0 {
+    err = errors.New("No valid hosts left to submit!")
+  }
+  if err != nil {
+    exitFailed(opts, "enable", strings.Join(maint.Hosts, ","), "", err)
+  }
+  maint.Hosts = hosts
+  opts.Host = hosts[0]
+  checkMaxHosts(opts, len(maint.Hosts), "hosts")
+
+  start, _ := parseStart(opts.Start)
+  if opts.AdjustForSkew {
+    measureSkew(ini)
+    start = start.Add(clockSkew)
+  }
+  dt := getDateTime(start, opts.Timeout)
+  if opts.SpecFile != "" {
+    dt = DT{ maint.StartTime, maint.EndTime }
+  }
+
+  if opts.PreviewTimes {
+    previewTimes(dt, opts)
+  }
+
+  checkMinWindow(opts, ini, dt)
+  for _, host := range maint.Hosts {
+    checkEnableRate(host, opts, ini)
+  }
+
+  // -- warn about overlapping maintenances --
+  if opts.CheckOverlap {
+    checkOverlap(opts, ini, MAINT{ Hosts: maint.Hosts, StartTime: dt.startTime, EndTime: dt.endTime })
+  }
+
+  // -- nothing to do if every host is already covered --
+  if opts.SkipIfActive {
+    var ids []string
+    for _, host := range maint.Hosts {
+      id := coveringMaintenance(host, dt, ini)
+      if id == "" {
+        ids = nil
+        break
+      }
+      ids = append(ids, id)
+    }
+    if len(ids) > 0 {
+      if !opts.Silent {
+        fmt.Printf("%s already in maintenance (%s)\n", strings.Join(maint.Hosts, ","), strings.Join(ids, ","))
+      }
+      os.Exit(0)
+    }
+  }
+
+  // -- prepare json --
+  if opts.SpecFile == "" {
+    owners, err := effectiveOwners(opts, ini)
+    if err != nil {
+      exitFailed(opts, "enable", strings.Join(maint.Hosts, ","), "", err)
+    }
+    maint = buildMaint(opts.Host, dt, owners, opts, ini)
+  }
+
+  maint.Comment = limitComment(maint.Comment, opts, ini)
+  mustValidate(opts, "enable", strings.Join(maint.Hosts, ","), maint)
+
+  // -- verify owner is authorized --
+  if opts.CheckOwner {
+    for _, host := range maint.Hosts {
+      checkOwner(opts, ini, host, maint.Owners)
+    }
+  }
+
+  e, err := enablePayload(maint, opts)
+  if err != nil {
+    fmt.Println(err)
+    return
+  }
+  if (!opts.Silent && opts.Output != "json") || opts.DryRun {
+    fmt.Println(string(e))
+  }
+  if opts.DryRun {
+    os.Exit(0)
+  }
+  if opts.ChunkSize > 0 && len(maint.Hosts) > opts.ChunkSize {
+    enableChunks(maint, opts, ini)
+  }
+
+  result, err := MaintEnable(maint, opts, ini)
+  if err != nil && result.Status == 0 {
+    logEvent("enable", strings.Join(maint.Hosts, ","), "", err.Error())
+    fmt.Fprintln(os.Stderr, err.Error())
+    os.Exit(3)
+  }
+  if opts.Output != "json" {
+    reportRetries(opts)
+  }
+
+  // -- print normalized record, raw body if it doesn't parse --
+  parsed := len(result.Maintenances) > 0
+  if !opts.Silent {
+    if opts.Output == "json" && parsed {
+      enabled := ENABLERESULT{ Result: enableOutcome(result), ID: result.IDs[0], Maintenance: result.Maintenances[0] }
+      if opts.ReportRetries {
+        enabled.Retries = &retryStats
+      }
+      out := marshalOutput(enabled, opts)
+      fmt.Println(string(out))
+    } else {
+      fmt.Println(string(result.Raw))
+    }
+  }
+
+  id := ""
+  if parsed {
+    id = result.IDs[0]
+  }
+  if err != nil {
+    logEvent("enable", strings.Join(maint.Hosts, ","), id, err.Error())
+    os.Exit(3)
+  }
+
+  logEvent("enable", strings.Join(maint.Hosts, ","), id, "ok")
+  notifyWebhook(opts, ini, "enable", strings.Join(maint.Hosts, ","), id)
+
+  // -- future windows start out as scheduled --
+  if begin, perr := time.Parse(time.RFC3339, maint.StartTime); perr == nil && begin.After(time.Now()) && !opts.Silent && opts.Output != "json" {
+    status := "scheduled"
+    if parsed && result.Maintenances[0].Status != "" {
+      status = result.Maintenances[0].Status
+    }
+    fmt.Printf("Maintenance is %s and will begin at %s (in %s)\n", status, maint.StartTime, time.Until(begin).Round(time.Second))
+  }
+
+  // -- link to the web ui --
+  if ini.WebBaseURL != "" && id != "" && !opts.Silent && opts.Output != "json" {
+    fmt.Printf("View: %s\n", webURL(ini, id))
+  }
+
+  rememberEnable(maint.Hosts, id, opts, ini)
+  os.Exit(0)
+}
+
+// --- remember id for a later --disable --host, count enables for --rate-limit ---
+func rememberEnable(hosts []string, id string, opts options, ini INI) {
+  path := cachePath(opts, ini)
+  if path == "" || id == "" || len(hosts) == 0 {
+    return
+  }
+  cache := loadCache(path)
+  cache.IDs[hosts[0]] = id
+  if opts.RateLimit > 0 {
+    for _, host := range hosts {
+      recordEnable(&cache, host, opts.RateWindow)
+    }
+  }
+  if err := saveCache(path, cache); err != nil {
+    fmt.Fprintf(os.Stderr, "Warning: cannot write id cache %s - %s\n", path, err.Error())
+  }
+}
+
+// --- replace maintenance by id with new values ---
+func MaintUpdate(id string, maint MAINT, ini INI) (Result, error) {
+  result := Result{ Action: "update", IDs: []string{id} }
+
+  e, err := json.Marshal(maint)
+  if err != nil {
+    return result, err
+  }
+  req, err := newRequest("PUT", apiURL(ini, id), e, ini)
+  if err != nil {
+    return result, err
+  }
+  resp, err := doRequest(req)
+  if err != nil {
+    return result, err
+  }
+  defer resp.Body.Close()
+
+  result.Status = resp.StatusCode
+  result.Raw, err = ioutil.ReadAll(resp.Body)
+  if err != nil {
+    return result, err
+  }
+  if resp.StatusCode < 200 || resp.StatusCode > 299 {
+    return result, fmt.Errorf("%s", resp.Status)
+  }
+
+  var updated RESPONSE
+  if json.Unmarshal(result.Raw, &updated) == nil {
+    result.Maintenances = []RESPONSE{updated}
+  }
+  return result, nil
+}
+
+// --- existing maintenance with the changes requested by flags ---
+func requestedUpdate(current RESPONSE, opts options, ini INI) (MAINT, error) {
+  // -- keep the record's owners, ours only if it has none --
+  owners := current.Owners
+  if len(owners) == 0 {
+    var err error
+    owners, err = effectiveOwners(opts, ini)
+    if err != nil {
+      return MAINT{}, err
+    }
+  }
+
+  maint := MAINT{
+    Name:        current.Name,
+    Hosts:       current.Hosts,
+    AllServices: current.AllServices,
+    StartTime:   current.StartTime,
+    EndTime:     current.EndTime,
+    Owners:      owners,
+    Comment:     current.Comment,
+    RPD:         current.Rpd,
+    Author:      current.Author,
+    ServicePattern: current.ServicePattern,
+    Reason:      current.Reason,
+  }
+  if opts.Duration > 0 {
+    maint.EndTime = time.Now().Add(opts.Duration).Format(time.RFC3339)
+  }
+  if opts.EndAt != "" {
+    // -- only shorten, between now and the current end --
+    endAt, err := time.Parse(time.RFC3339, opts.EndAt)
+    if err != nil {
+      return MAINT{}, fmt.Errorf("Invalid --end-at %q, must be RFC3339", opts.EndAt)
+    }
+    if endAt.Before(time.Now()) {
+      return MAINT{}, fmt.Errorf("--end-at %s is in the past", opts.EndAt)
+    }
+    if end, err := time.Parse(time.RFC3339, current.EndTime); err == nil && endAt.After(end) {
+      return MAINT{}, fmt.Errorf("--end-at %s is after the current end %s", opts.EndAt, current.EndTime)
+    }
+    maint.EndTime = endAt.Format(time.RFC3339)
+  }
+  if opts.Comment != "" {
+    maint.Comment = limitComment(opts.Comment, opts, ini)
+  }
+  if opts.NoComment {
+    maint.Comment = ""
+  }
+  if opts.RPD != 0 {
+    maint.RPD = opts.RPD
+  }
+  if opts.ServicePattern != "" {
+    maint.ServicePattern = opts.ServicePattern
+  }
+  if opts.NoAllServices || opts.ServicePattern != "" {
+    maint.AllServices = false
+  }
+  if opts.Author != "" {
+    maint.Author = opts.Author
+  }
+  if opts.Reason != "" {
+    maint.Reason = reasonField(opts, ini)
+    // -- replace the tag of the current comment instead of stacking another --
+    if ini.ReasonInComment && opts.Comment == "" {
+      maint.Comment = stripReasonTag(maint.Comment)
+    }
+    maint.Comment = reasonComment(maint.Comment, opts, ini)
+  }
+  return maint, nil
+}
+
+// --- "field: old -> new" for every changed field ---
+func diffMaint(current RESPONSE, maint MAINT) []string {
+  var lines []string
+
+  changed := func(field string, old string, new string) {
+    if old != new {
+      lines = append(lines, fmt.Sprintf("%s: %s -> %s", field, old, new))
+    }
+  }
+  changed("endTime", current.EndTime, maint.EndTime)
+  changed("comment", current.Comment, maint.Comment)
+  changed("rpd", strconv.Itoa(current.Rpd), strconv.Itoa(maint.RPD))
+  changed("allServices", strconv.FormatBool(current.AllServices), strconv.FormatBool(maint.AllServices))
+  changed("owners", strings.Join(current.Owners, ", "), strings.Join(maint.Owners, ", "))
+  changed("servicePattern", current.ServicePattern, maint.ServicePattern)
+  changed("reason", current.Reason, maint.Reason)
+  return lines
+}
+
+// --- update existing maintenance ---
+func maint_update(opts options, ini INI) {
+  if len(opts.IDs) != 1 {
+    if !opts.Silent {
+      fmt.Println("Exactly one maintenance id must be provided for update!")
+    }
+    os.Exit(3)
+  }
+  id := opts.IDs[0]
+
+  // -- fetch current record --
+  current, status, err := getMaintenance(id, ini)
+  if err != nil {
+    logEvent("update", "", id, err.Error())
+    if !opts.Silent {
+      fmt.Printf("Cannot fetch maintenance %s - %s\n", id, err.Error())
+    }
+    if status == http.StatusNotFound {
+      os.Exit(1)
+    }
+    os.Exit(3)
+  }
+
+  maint, err := requestedUpdate(current, opts, ini)
+  if err != nil {
+    exitFailed(opts, "update", strings.Join(current.Hosts, ","), id, err)
+  }
+
+  // -- show changes --
+  if opts.Diff || opts.DryRun {
+    lines := diffMaint(current, maint)
+    if len(lines) == 0 {
+      lines = []string{"no changes"}
+    }
+    for _, l := range lines {
+      fmt.Println(l)
+    }
+  }
+  if opts.DryRun {
+    os.Exit(0)
+  }
+
+  mustValidate(opts, "update", strings.Join(maint.Hosts, ","), maint)
+
+  result, err := MaintUpdate(id, maint, ini)
+  if err != nil && result.Status == 0 {
+    logEvent("update", strings.Join(maint.Hosts, ","), id, err.Error())
+    fmt.Fprintln(os.Stderr, err.Error())
+    os.Exit(3)
+  }
+  reportRetries(opts)
+  if !opts.Silent {
+    if opts.Output == "json" && len(result.Maintenances) > 0 {
+      fmt.Println(string(marshalOutput(result.Maintenances[0], opts)))
+    } else {
+      fmt.Println(string(result.Raw))
+    }
+    if opts.EndAt != "" && err == nil {
+      end := maint.EndTime
+      if len(result.Maintenances) > 0 && result.Maintenances[0].EndTime != "" {
+        end = result.Maintenances[0].EndTime
+      }
+      fmt.Fprintf(os.Stderr, "Maintenance %s now ends at %s\n", id, end)
+    }
+  }
+  if err != nil {
+    logEvent("update", strings.Join(maint.Hosts, ","), id, err.Error())
+    os.Exit(3)
+  }
+  logEvent("update", strings.Join(maint.Hosts, ","), id, "ok")
+  notifyWebhook(opts, ini, "update", strings.Join(maint.Hosts, ","), id)
+  os.Exit(0)
+}
+
+// --- push out the end of every active maintenance of host ---
+func maint_refresh(opts options, ini INI) {
+  var failed int
+
+  if err := checkHost(opts.Host, opts, ini); err != nil {
+    if !opts.Silent {
+      fmt.Println(err.Error())
+    }
+    os.Exit(3)
+  }
+
+  active := getMaintenances(opts.Host, "active", ini)
+  if len(active) == 0 {
+    if !opts.Silent {
+      fmt.Printf("No active maintenances for %s\n", opts.Host)
+    }
+    os.Exit(1)
+  }
+  checkMaxHosts(opts, len(active), "maintenances")
+
+  for _, current := range active {
+    id := current.MaintenanceId
+    maint, err := requestedUpdate(current, opts, ini)
+    if err != nil {
+      if !opts.Silent {
+        fmt.Println(err.Error())
+      }
+      os.Exit(3)
+    }
+
+    // -- never shorten a window that already ends later --
+    end, err1 := time.Parse(time.RFC3339, current.EndTime)
+    newEnd, err2 := time.Parse(time.RFC3339, maint.EndTime)
+    if err1 == nil && err2 == nil && !newEnd.After(end) {
+      if !opts.Silent {
+        fmt.Printf("Skipped %s, already ends at %s\n", id, current.EndTime)
+      }
+      continue
+    }
+    if opts.DryRun {
+      fmt.Printf("Would refresh %s: %s -> %s\n", id, current.EndTime, maint.EndTime)
+      continue
+    }
+
+    _, err = MaintUpdate(id, maint, ini)
+    if err != nil {
+      failed++
+      logEvent("update", opts.Host, id, err.Error())
+      if !opts.Silent {
+        fmt.Printf("Failed to refresh %s - %s\n", id, err.Error())
+      }
+      if opts.FailFast {
+        break
+      }
+      continue
+    }
+    logEvent("update", opts.Host, id, "ok")
+    notifyWebhook(opts, ini, "update", opts.Host, id)
+    if !opts.Silent {
+      fmt.Printf("Refreshed %s until %s\n", id, maint.EndTime)
+    }
+  }
+
+  reportRetries(opts)
+  if failed > 0 {
+    os.Exit(3)
+  }
+  os.Exit(0)
+}
+
+// --- post action summary to webhook, only warn on failure ---
+func notifyWebhook(opts options, ini INI, action string, host string, id string) {
+  url := opts.Webhook
+  if url == "" {
+    url = ini.WebhookURL
+  }
+  if url == "" {
+    return
+  }
+
+  e, _ := json.Marshal(NOTIFICATION{ action, host, id, effectiveAuthor(opts), time.Now().Format(time.RFC3339) })
+  req, err := http.NewRequestWithContext(opCtx, "POST", url, bytes.NewReader(e))
+  if err == nil {
+    req.Header.Set("Content-Type", "application/json")
+    var resp *http.Response
+    resp, err = httpClient.Do(req)
+    if err == nil {
+      resp.Body.Close()
+      if resp.StatusCode < 200 || resp.StatusCode > 299 {
+        err = fmt.Errorf("%s", resp.Status)
+      }
+    }
+  }
+  if err != nil {
+    fmt.Fprintf(os.Stderr, "Warning: webhook notification failed - %s\n", err.Error())
+  }
+}
+
+// --- report action result to --output events, hooks and syslog ---
+func logEvent(action string, host string, id string, result string) {
+  if emitEvents {
+    printEvent(action, host, id, result)
+  }
+  if result == "ok" {
+    runHook(onSuccess, action, host, id, result)
+  } else {
+    runHook(onFailure, action, host, id, result)
+  }
+  if eventLog == nil {
+    return
+  }
+  msg := fmt.Sprintf("action=%s host=%q id=%q result=%q", action, host, id, result)
+  var err error
+  if result == "ok" {
+    err = eventLog.Success(msg)
+  } else {
+    err = eventLog.Failure(msg)
+  }
+  if err != nil {
+    fmt.Fprintf(os.Stderr, "Warning: syslog write failed - %s\n", err.Error())
+  }
+}
+
+// --- report failed action through logEvent and exit 3 ---
+func exitFailed(opts options, action string, host string, id string, err error) {
+  logEvent(action, host, id, err.Error())
+  if !opts.Silent {
+    fmt.Println(err.Error())
+  }
+  os.Exit(3)
+}
+
+// --- run hook command with action context in the environment ---
+func runHook(command string, action string, host string, id string, result string) {
+  if command == "" {
+    return
+  }
+  cmd := exec.Command("sh", "-c", command)
+  if runtime.GOOS == "windows" {
+    cmd = exec.Command("cmd", "/C", command)
+  }
+  cmd.Env = append(os.Environ(),
+    "ICINGA_ACTION=" + action,
+    "ICINGA_HOST=" + host,
+    "ICINGA_ID=" + id,
+    "ICINGA_STATUS=" + result,
+  )
+  cmd.Stdout = os.Stderr
+  cmd.Stderr = os.Stderr
+  if err := cmd.Run(); err != nil {
+    fmt.Fprintf(os.Stderr, "Warning: hook %q failed - %s\n", command, err.Error())
+  }
+}
+
+// --- event names per action, failures are maintenance.failed ---
+var eventNames = map[string]string {
+  "enable":     "maintenance.created",
+  "update":     "maintenance.updated",
+  "disable":    "maintenance.deleted",
+  "disableall": "maintenance.deleted",
+}
+
+// --- one NDJSON event line on stdout ---
+func printEvent(action string, host string, id string, result string) {
+  e := EVENT{ TS: time.Now().UTC().Format(time.RFC3339Nano), Event: eventNames[action], Action: action, Host: host, ID: id }
+  if result != "ok" || e.Event == "" {
+    e.Event = "maintenance.failed"
+    e.Error = result
+  }
+  line, _ := json.Marshal(e)
+  fmt.Println(string(line))
+}
+
+// --- read maintenance ids from file (one per line, # comments) ---
+func readIDs(file string) ([]string, error) {
+  var ids []string
+
+  f, err := os.Open(file)
+  if err != nil {
+    return nil, err
+  }
+  defer f.Close()
+
+  scanner := bufio.NewScanner(f)
+  for scanner.Scan() {
+    line := strings.TrimSpace(scanner.Text())
+    if line == "" || strings.HasPrefix(line, "#") {
+      continue
+    }
+    ids = append(ids, line)
+  }
+  return ids, scanner.Err()
+}
+
+// --- delete single maintenance by id ---
+func MaintDisable(id string, ini INI) (Result, error) {
+  var str       []byte
+
+  result := Result{ Action: "disable", IDs: []string{id} }
+  url  := apiURL(ini, id)
+
+  req, err := newRequest("DELETE", url, str, ini)
+  if err != nil {
+    return result, err
+  }
+  resp, err := doRequest(req)
+  if err != nil {
+    return result, err
+  }
+  defer resp.Body.Close()
+
+  result.Status = resp.StatusCode
+  result.Raw, err = ioutil.ReadAll(resp.Body)
+  if err != nil {
+    return result, err
+  }
+  if resp.StatusCode < 200 || resp.StatusCode > 299 {
+    return result, fmt.Errorf("%s", resp.Status)
+  }
+  return result, nil
+}
+
+// --- disable (delete) maintenacse mode ---
+func maint_disable(opts options, ini INI) {
+  ids := opts.IDs
+
+  // -- collect ids from file --
+  if opts.IDsFile != "" {
+    fileIDs, err := readIDs(opts.IDsFile)
+    if err != nil {
+      if !opts.Silent {
+        fmt.Printf("Cannot read ids file %s - %s\n", opts.IDsFile, err.Error())
+      }
+      os.Exit(3)
+    }
+    ids = append(ids, fileIDs...)
+  }
+
+  // -- fall back to cached id for host --
+  fromCache := false
+  if len(ids) == 0 && opts.Host != "" && cachePath(opts, ini) != "" {
+    id, err := cachedID(opts.Host, opts, ini)
+    if err != nil {
+      if !opts.Silent {
+        fmt.Println(err.Error())
+      }
+      os.Exit(3)
+    }
+    ids = []string{id}
+    fromCache = true
+  }
+
+  // -- verify if maintenence ID provided --
+  if len(ids) == 0 {
+    if !opts.Silent {
+      fmt.Println("Maintenance id must be provided for deletion!")
+    }
+    os.Exit(3)
+  }
+
+  checkMaxHosts(opts, len(ids), "maintenances")
+
+  // -- excute one DELETE per id --
+  var failed []string
+  var deleted []string
+  var missing []string
+  done := 0
+  if len(ids) > 1 {
+    handleSignals()
+  }
+  for _, id := range ids {
+    if interrupted() {
+      break
+    }
+    result, err := MaintDisable(id, ini)
+    done++
+    if err != nil && opts.IgnoreMissing && result.Status == http.StatusNotFound {
+      if !opts.Silent {
+        fmt.Printf("Maintenance %s not found, nothing to delete\n", id)
+      }
+      missing = append(missing, id)
+      continue
+    }
+    if !opts.Silent && len(result.Raw) > 0 {
+      fmt.Println(string(result.Raw))
+    }
+    if err != nil {
+      logEvent("disable", opts.Host, id, err.Error())
+      failed = append(failed, id)
+      if !opts.Silent {
+        fmt.Printf("Failed to delete %s - %s\n", id, err.Error())
+      }
+      if opts.FailFast {
+        break
+      }
+    } else {
+      logEvent("disable", opts.Host, id, "ok")
+      notifyWebhook(opts, ini, "disable", opts.Host, id)
+      deleted = append(deleted, id)
+    }
+  }
+
+  reportRetries(opts)
+  if (len(ids) > 1 || interrupted()) && !opts.Silent {
+    fmt.Printf("Deleted %d of %d maintenances, %d not found, %d failed, %d skipped\n", len(deleted), len(ids), len(missing), len(failed), len(ids)-done)
+    for _, id := range missing {
+      fmt.Printf("  not found: %s\n", id)
+    }
+    for _, id := range failed {
+      fmt.Printf("  failed: %s\n", id)
+    }
+  }
+  if interrupted() {
+    fmt.Fprintf(os.Stderr, "Interrupted, deleted: %s\n", strings.Join(deleted, ","))
+    os.Exit(130)
+  }
+
+  if len(failed) > 0 {
+    os.Exit(3)
+  }
+
+  // -- drop deleted id from cache --
+  if fromCache {
+    path := cachePath(opts, ini)
+    cache := loadCache(path)
+    delete(cache.IDs, opts.Host)
+    saveCache(path, cache)
+  }
+  os.Exit(0)
+}
+
+// --- delete all maintenances for host ---
+func MaintDisableHost(host string, ini INI) (Result, error) {
+  var str       []byte
+
+  result := Result{ Action: "disableall" }
+  url  := apiURL(ini, "host", host)
+
+  req, err := newRequest("DELETE", url, str, ini)
+  if err != nil {
+    return result, err
+  }
+  resp, err := doRequest(req)
+  if err != nil {
+    return result, err
+  }
+  defer resp.Body.Close()
+
+  result.Status = resp.StatusCode
+  result.Raw, err = ioutil.ReadAll(resp.Body)
+  if err != nil {
+    return result, err
+  }
+  if resp.StatusCode < 200 || resp.StatusCode > 299 {
+    return result, fmt.Errorf("%s", resp.Status)
+  }
+  return result, nil
+}
+
+// --- disable (delete) all maintenacse for host ---
+func maint_disableHost(opts options, ini INI) {
+  // -- verify if provided host is valid (DNS) --
+  if err := checkHost(opts.Host, opts, ini); err != nil {
+    exitFailed(opts, "disableall", opts.Host, "", err)
+  }
+    
+  // -- excute --
+  result, err := MaintDisableHost(opts.Host, ini)
+  if err != nil && result.Status == 0 {
+    logEvent("disableall", opts.Host, "", err.Error())
+    fmt.Fprintln(os.Stderr, err.Error())
+    os.Exit(3)
+  }
+  reportRetries(opts)
+  if err != nil && opts.IgnoreMissing && result.Status == http.StatusNotFound {
+    if !opts.Silent {
+      fmt.Printf("No maintenances found for %s, nothing to delete\n", opts.Host)
+    }
+    os.Exit(0)
+  }
+
+  if !opts.Silent {
+    fmt.Println(string(result.Raw))
+  }
+  if err != nil {
+    logEvent("disableall", opts.Host, "", err.Error())
+    os.Exit(3)
+  }
+
+  logEvent("disableall", opts.Host, "", "ok")
+  notifyWebhook(opts, ini, "disableall", opts.Host, "")
+
+  // -- report how many were deleted --
+  count, ok := deletedCount(result.Raw)
+  if ok && !opts.Silent {
+    fmt.Printf("Deleted %d maintenances for host %s\n", count, opts.Host)
+  }
+  if ok && count == 0 {
+    os.Exit(1)
+  }
+  os.Exit(0)
+}
+
+// --- number of deleted maintenances from array or {count|deleted: n} body ---
+func deletedCount(body []byte) (int, bool) {
+  var list    []json.RawMessage
+  var result  map[string]interface{}
+
+  if json.Unmarshal(body, &list) == nil {
+    return len(list), true
+  }
+  if json.Unmarshal(body, &result) == nil {
+    for _, key := range []string{"count", "deleted", "deletedCount"} {
+      if n, ok := result[key].(float64); ok {
+        return int(n), true
+      }
+    }
+  }
+  return 0, false
+}
+
+// --- fetch maintenances for host, partial results on errTruncated ---
+func MaintGet(host string, status string, ini INI) (Result, error) {
+  response, code, err := fetchMaintenances(apiURL(ini, "host", "all", host) + "?status=" + status, ini)
+
+  result := Result{ Action: "get", Status: code, Maintenances: response }
+  for _, r := range response {
+    result.IDs = append(result.IDs, r.MaintenanceId)
+  }
+  return result, err
+}
+
+// --- probe url with HEAD, returns http status ---
+func headStatus(url string, ini INI) (int, error) {
+  var str       []byte
+
+  req, err := newRequest("HEAD", url, str, ini)
+  if err != nil {
+    return 0, err
+  }
+  resp, err := doRequest(req)
+  if err != nil {
+    return 0, err
+  }
+  resp.Body.Close()
+  return resp.StatusCode, nil
+}
+
+// --- check existence by id or host, exit code only ---
+func maint_exists(opts options, ini INI) {
+  exists := false
+
+  if len(opts.IDs) > 0 {
+    url := apiURL(ini, opts.IDs[0])
+    status, err := headStatus(url, ini)
+    if err == nil && status == http.StatusMethodNotAllowed {
+      _, status, err = getMaintenance(opts.IDs[0], ini)
+      if status == http.StatusOK {
+        err = nil
+      }
+    }
+    if status == 0 {
+      fmt.Fprintln(os.Stderr, err.Error())
+      os.Exit(3)
+    }
+    exists = status == http.StatusOK
+  } else {
+    // -- the list answers 200 with [] too, HEAD can't tell it's empty --
+    exists = len(getMaintenances(opts.Host, opts.Status, ini)) > 0
+  }
+
+  if exists {
+    os.Exit(0)
+  }
+  os.Exit(1)
+}
+
+// --- write maintenances in the selected output format ---
+func writeMaintenances(w io.Writer, response []RESPONSE, opts options, ini INI, color bool, loc *time.Location) {
+  if opts.Output == "csv" {
+    writeCSV(w, response, opts)
+  } else if opts.Output == "jsonl" {
+    for _, resp := range response {
+      var line []byte
+      if opts.Fields != "" {
+        fields, _ := parseFields(opts.Fields)
+        line, _ = json.Marshal(projectFields([]RESPONSE{resp}, fields)[0])
+      } else {
+        line, _ = json.Marshal(resp)
+      }
+      fmt.Fprintln(w, string(line))
+    }
+  } else if opts.Output == "json" {
+    var out []byte
+    if opts.Fields != "" {
+      fields, _ := parseFields(opts.Fields)
+      out = marshalOutput(projectFields(response, fields), opts)
+    } else {
+      out = marshalOutput(response, opts)
+    }
+    fmt.Fprintln(w, string(out))
+  } else if opts.SummaryOnly {
+    fmt.Fprintln(w, summaryLine(response, ini.StatusLabels))
+  } else if opts.Compact {
+    for _, resp := range response {
+      fmt.Fprintf(w, "%s %s %s %s\n", resp.MaintenanceId, strings.Join(resp.Hosts, ","), colorStatus(resp.Status, ini.StatusLabels, color), displayTime(resp.EndTime, loc))
+    }
+  } else {
+    for i, resp := range response {
+      serv := "false"
+      if resp.AllServices {
+        serv = "true"
+      }
+      fmt.Fprintf(w, "\n ------------- Maintenance #%d -------------\n", i+1)
+      fmt.Fprintf(w, "nmaintenanceId: %s\n", resp.MaintenanceId)
+      fmt.Fprintf(w, "name: %s\n", resp.Name)
+      fmt.Fprintf(w, "type: %s\n", resp.Type)
+      fmt.Fprintf(w, "hosts: %s\n", resp.Hosts[0])
+      fmt.Fprintf(w, "allServices: %s\n", serv)
+      fmt.Fprintf(w, "startTime: %s\n", displayTime(resp.StartTime, loc))
+      fmt.Fprintf(w, "endTime: %s\n", displayTime(resp.EndTime, loc))
+      fmt.Fprintf(w, "createdBy: %s\n", resp.CreatedBy)
+      fmt.Fprintf(w, "creationTime: %s\n", displayTime(resp.CreationTime, loc))
+      fmt.Fprintf(w, "updatedBy: %s\n", resp.UpdatedBy)
+      fmt.Fprintf(w, "updationTime: %s\n", displayTime(resp.UpdationTime, loc))
+      fmt.Fprintf(w, "status: %s\n", colorStatus(resp.Status, ini.StatusLabels, color))
+      fmt.Fprintf(w, "comment: %s\n", resp.Comment)
+      if url := commentBuildURL(resp.Comment); url != "" {
+        fmt.Fprintf(w, "build: %s\n", url)
+      }
+      fmt.Fprintf(w, "rpd: %d\n", resp.Rpd)
+      if resp.Author != "" {
+        fmt.Fprintf(w, "author: %s\n", resp.Author)
+      }
+      if opts.History {
+        printHistory(w, resp, ini)
+      }
+    }
+    if opts.Summary {
+      fmt.Fprintf(w, "\n%s\n", summaryLine(response, ini.StatusLabels))
+    }
+  }
+}
+
+var csvDefaultFields = "maintenanceId,hosts,status,startTime,endTime,createdBy,comment"
+
+// --- csv with header row, --fields selects columns, hosts joined with ',' ---
+func writeCSV(w io.Writer, response []RESPONSE, opts options) {
+  list := opts.Fields
+  if list == "" {
+    list = csvDefaultFields
+  }
+  fields, _ := parseFields(list)
+
+  if opts.CSVBOM {
+    w.Write([]byte("\xef\xbb\xbf"))
+  }
+  cw := csv.NewWriter(w)
+  cw.Comma = csvDelimiter(opts.CSVDelimiter)
+  cw.Write(fields)
+  for _, m := range projectFields(response, fields) {
+    var row []string
+    for _, f := range fields {
+      switch v := m[f].(type) {
+      case nil:
+        row = append(row, "")
+      case []interface{}:
+        var parts []string
+        for _, p := range v {
+          parts = append(parts, fmt.Sprint(p))
+        }
+        row = append(row, strings.Join(parts, ","))
+      default:
+        row = append(row, fmt.Sprint(v))
+      }
+    }
+    cw.Write(row)
+  }
+  cw.Flush()
+}
+
+// --- --csv-delimiter as rune, "tab" for tab separated ---
+func csvDelimiter(d string) rune {
+  if d == "tab" || d == "\\t" {
+    return '\t'
+  }
+  return []rune(d)[0]
+}
+
+// --- one file per host in --output-dir, named <host>.json or <host>.txt ---
+func writeHostFiles(response []RESPONSE, opts options, ini INI, loc *time.Location) {
+  var order []string
+
+  byHost := map[string][]RESPONSE{}
+  for _, r := range response {
+    for _, h := range r.Hosts {
+      if _, ok := byHost[h]; !ok {
+        order = append(order, h)
+      }
+      byHost[h] = append(byHost[h], r)
+    }
+  }
+
+  if err := os.MkdirAll(opts.OutputDir, 0755); err != nil {
+    fmt.Fprintf(os.Stderr, "Cannot create output directory %s - %s\n", opts.OutputDir, err.Error())
+    os.Exit(3)
+  }
+  ext := ".txt"
+  if opts.Output != "text" {
+    ext = "." + opts.Output
+  }
+  written := 0
+  for _, h := range order {
+    path := filepath.Join(opts.OutputDir, filepath.Base(h) + ext)
+    f, err := os.Create(path)
+    if err != nil {
+      fmt.Fprintf(os.Stderr, "Cannot write %s - %s\n", path, err.Error())
+      continue
+    }
+    writeMaintenances(f, byHost[h], opts, ini, false, loc)
+    f.Close()
+    written++
+  }
+  if !opts.Silent {
+    fmt.Fprintf(os.Stderr, "Wrote %d files to %s\n", written, opts.OutputDir)
+  }
+}
+
+// --- get maintenance information for host ---
+func maint_get(opts options, ini INI) {
+  var response []RESPONSE
+
+  // -- exit found/none, defaults 0/1 --
+  found, none := opts.ExitFound, opts.ExitNone
+  if opts.InvertExit {
+    found, none = none, found
+  }
+
+  if streamable(opts) && len(opts.IDs) == 0 {
+    url := apiURL(ini, "all") + "?status=" + opts.Status
+    if opts.HostContains == "" {
+      if err := checkHost(opts.Host, opts, ini); err != nil {
+        fmt.Println(err.Error())
+        os.Exit(3)
+      }
+      url = apiURL(ini, "host", "all", opts.Host) + "?status=" + opts.Status
+    }
+    if streamMaintenances(url, opts, ini) > 0 {
+      os.Exit(found)
+    }
+    os.Exit(none)
+  }
+
+  if len(opts.IDs) > 0 {
+    // -- fetch records by id --
+    for _, id := range opts.IDs {
+      m, status, err := getMaintenance(id, ini)
+      if err != nil {
+        if !opts.Silent {
+          fmt.Printf("Cannot fetch maintenance %s - %s\n", id, err.Error())
+        }
+        if status == http.StatusNotFound {
+          os.Exit(1)
+        }
+        os.Exit(3)
+      }
+      response = append(response, m)
+    }
+  } else if opts.HostContains != "" {
+    // -- match hosts client side over the full list --
+    var hosts int
+    response, hosts = filterHostContains(listMaintenances(opts.Status, ini), opts.HostContains)
+    if !opts.Silent {
+      fmt.Fprintf(os.Stderr, "%d hosts match %q\n", hosts, opts.HostContains)
+    }
+  } else {
+    // -- check host --
+    if err := checkHost(opts.Host, opts, ini); err != nil {
+      if !opts.Silent {
+        fmt.Println(err.Error())
+      }
+      os.Exit(3)
+    }
+    result, err := MaintGet(opts.Host, opts.Status, ini)
+    response = mustMaintenances(result.Maintenances, result.Status, err)
+  }
+
+  reportRetries(opts)
+
+  // -- filter by creator --
+  if opts.CreatedBy != "" {
+    response = filterCreatedBy(response, []string{opts.CreatedBy})
+  }
+  if opts.OnlyExpired {
+    response = filterExpired(response, time.Now())
+  }
+  if opts.OnlyMine {
+    mine, _ := effectiveOwners(opts, ini)
+    if user := os.Getenv("USER"); user != "" {
+      mine = append(mine, user)
+    }
+    response = filterCreatedBy(response, mine)
+  }
+
+  if opts.MergeAdjacent {
+    response = mergeAdjacent(response)
+  }
+
+  // -- sort, first match defaults to earliest ending --
+  sortKey := opts.SortBy
+  if sortKey == "" && opts.FirstMatch {
+    sortKey = "end"
+  }
+  sortMaintenances(response, sortKey, opts.Reverse)
+
+  if opts.FirstMatch && len(response) > 0 {
+    response = response[:1]
+  }
+
+  if opts.Redact {
+    response = redactResponses(response, opts.RedactFields)
+  }
+
+  quiet := opts.Silent || (opts.QuietOnEmpty && len(response) == 0)
+  color := useColor(opts.Color)
+  var loc *time.Location
+  if opts.DisplayTZ != "" {
+    loc, _ = time.LoadLocation(opts.DisplayTZ)
+  }
+  if opts.OutputDir != "" {
+    writeHostFiles(response, opts, ini, loc)
+  } else if !quiet {
+    writeMaintenances(os.Stdout, response, opts, ini, color, loc)
+  }
+
+  if len(response) > 0 {
+    os.Exit(found)
+  } else {
+    os.Exit(none)
+  }
+}
+
+// --- tally maintenances overlapping [from, to), hours clipped to the range ---
+func buildReport(response []RESPONSE, from time.Time, to time.Time) REPORT {
+  report := REPORT{From: from.Format(time.RFC3339), To: to.Format(time.RFC3339), ByStatus: map[string]int{}, TopHosts: []HOSTCOUNT{}}
+  hosts := map[string]int{}
+
+  for _, r := range response {
+    start, err1 := time.Parse(time.RFC3339, r.StartTime)
+    end, err2 := time.Parse(time.RFC3339, r.EndTime)
+    if err1 != nil || err2 != nil || !start.Before(to) || !end.After(from) {
+      continue
+    }
+    if start.Before(from) {
+      start = from
+    }
+    if end.After(to) {
+      end = to
+    }
+    report.Total++
+    report.ByStatus[r.Status]++
+    report.Hours += end.Sub(start).Hours()
+    for _, h := range r.Hosts {
+      hosts[h]++
+    }
+  }
+
+  for h, n := range hosts {
+    report.TopHosts = append(report.TopHosts, HOSTCOUNT{h, n})
+  }
+  sort.Slice(report.TopHosts, func(i, j int) bool {
+    if report.TopHosts[i].Count != report.TopHosts[j].Count {
+      return report.TopHosts[i].Count > report.TopHosts[j].Count
+    }
+    return report.TopHosts[i].Host < report.TopHosts[j].Host
+  })
+  if len(report.TopHosts) > 10 {
+    report.TopHosts = report.TopHosts[:10]
+  }
+  return report
+}
+
+// --- maintenance report over all statuses ---
+func maint_report(opts options, ini INI) {
+  var response []RESPONSE
+
+  from, _ := parseStart(opts.ReportFrom)
+  to, _ := parseStart(opts.ReportTo)
+  for _, status := range []string{"active", "scheduled", "completed", "deleted"} {
+    response = append(response, listMaintenances(status, ini)...)
+  }
+  report := buildReport(response, from, to)
+
+  if opts.Output == "json" {
+    fmt.Println(string(marshalOutput(report, opts)))
+    os.Exit(0)
+  }
+  fmt.Printf("Maintenance report %s - %s\n", report.From, report.To)
+  fmt.Printf("Total: %d\n", report.Total)
+  for _, status := range []string{"active", "scheduled", "completed", "deleted"} {
+    if n := report.ByStatus[status]; n > 0 {
+      fmt.Printf("  %s: %d\n", status, n)
+    }
+  }
+  fmt.Printf("Maintenance hours: %.1f\n", report.Hours)
+  if len(report.TopHosts) > 0 {
+    fmt.Println("Top hosts:")
+    for _, h := range report.TopHosts {
+      fmt.Printf("  %-40s %d\n", h.Host, h.Count)
+    }
+  }
+  os.Exit(0)
+}
+
+// --- probe api endpoint conventions without changing anything ---
+func maint_probe(opts options, ini INI) {
+  var probes []PROBE
+  var str    []byte
+
+  host := opts.Host
+  if host == "" {
+    host = "probe.invalid"
+  }
+  id := "00000000-0000-0000-0000-000000000000"
+
+  // -- OPTIONS on write endpoints, 405 still proves the route exists --
+  write := []int{200, 204, 405}
+  probes = append(probes,
+    PROBE{Method: "GET", URL: apiURL(ini, "all") + "?status=active", Expected: []int{200}},
+    PROBE{Method: "GET", URL: apiURL(ini, "host", "all", host) + "?status=active", Expected: []int{200, 404}},
+    PROBE{Method: "HEAD", URL: apiURL(ini, id), Expected: []int{200, 404}},
+    PROBE{Method: "GET", URL: apiURL(ini, id, "history"), Expected: []int{200, 404}},
+    PROBE{Method: "OPTIONS", URL: apiURL(ini, "host"), Expected: write},
+    PROBE{Method: "OPTIONS", URL: apiURL(ini, "batch"), Expected: write},
+    PROBE{Method: "OPTIONS", URL: apiURL(ini, "host", host), Expected: write},
+  )
+  if ini.OwnersEndpoint != "" {
+    url := ini.OwnersEndpoint
+    if !strings.Contains(url, "://") {
+      url = apiURL(ini, url)
+    }
+    probes = append(probes, PROBE{Method: "GET", URL: url, Expected: []int{200}})
+  }
+
+  failed := 0
+  for i := range probes {
+    p := &probes[i]
+    req, err := newRequest(p.Method, p.URL, str, ini)
+    if err == nil {
+      var resp *http.Response
+      resp, err = doRequest(req)
+      if err == nil {
+        p.Status = resp.StatusCode
+        resp.Body.Close()
+      }
+    }
+    if err != nil {
+      p.Error = err.Error()
+    }
+    for _, e := range p.Expected {
+      if p.Status == e {
+        p.OK = true
+      }
+    }
+    if !p.OK {
+      failed++
+    }
+  }
+
+  if opts.Output == "json" {
+    fmt.Println(string(marshalOutput(probes, opts)))
+  } else {
+    for _, p := range probes {
+      result := "ok"
+      if !p.OK {
+        result = "MISMATCH"
+      }
+      status := strconv.Itoa(p.Status)
+      if p.Error != "" {
+        status = "error: " + p.Error
+      }
+      fmt.Printf("%-8s %-9s %-70s %s\n", p.Method, result, p.URL, status)
+    }
+    fmt.Printf("%d of %d endpoints as expected\n", len(probes)-failed, len(probes))
+  }
+  if failed > 0 {
+    os.Exit(3)
+  }
+  os.Exit(0)
+}
+
+// --- check inputs without calling the api, exit 0 if all is fine ---
+func maint_validate(opts options, ini INI) {
+  var problems []string
+  var maints   []MAINT
+  var hosts    []string
+
+  if ini.BaseURL == "" {
+    problems = append(problems, "config: BaseURL is not set")
+  }
+  owners, err := effectiveOwners(opts, ini)
+  if err != nil {
+    problems = append(problems, "config: " + err.Error())
+  }
+
+  // -- maintenances the run would submit --
+  start, _ := parseStart(opts.Start)
+  if opts.SpecFile != "" {
+    m, err := readSpecFile(opts, ini)
+    if err != nil {
+      problems = append(problems, fmt.Sprintf("spec file %s: %s", opts.SpecFile, err.Error()))
+    } else {
+      maints = append(maints, m)
+    }
+  } else if opts.Host != "" {
+    maints = append(maints, buildMaint(opts.Host, getDateTime(start, opts.Timeout), owners, opts, ini))
+  }
+  if opts.HostsFile != "" {
+    entries, err := readHostsFile(opts.HostsFile, opts.Timeout)
+    if err != nil {
+      problems = append(problems, fmt.Sprintf("hosts file %s: %s", opts.HostsFile, err.Error()))
+    }
+    for _, h := range dedupeEntries(entries, opts) {
+      hostOwners := owners
+      if len(h.Owners) > 0 {
+        hostOwners = h.Owners
+      }
+      maints = append(maints, buildMaint(h.Host, getDateTime(start, h.Timeout), hostOwners, opts, ini))
+    }
+  }
+  if opts.BatchFile != "" {
+    specs, err := readBatchFile(opts.BatchFile, opts, ini)
+    if err != nil {
+      problems = append(problems, fmt.Sprintf("batch file %s: %s", opts.BatchFile, err.Error()))
+    }
+    for _, spec := range specs {
+      spec.Hosts = dedupeHosts(normalizeHosts(spec.Hosts, opts), opts)
+      if len(spec.Owners) == 0 {
+        spec.Owners = owners
+      }
+      maints = append(maints, spec)
+    }
+  }
+
+  for _, m := range maints {
+    for _, err := range validateMaint(m) {
+      problems = append(problems, fmt.Sprintf("%s: %s", m.Name, err.Error()))
+    }
+    hosts = append(hosts, m.Hosts...)
+  }
+
+  // -- dns --
+  opts.OnBadHost = "abort"
+  hosts = dedupeHosts(hosts, opts)
+  if _, err := checkHosts(hosts, opts, ini); err != nil {
+    problems = append(problems, err.Error())
+  }
+
+  if len(problems) == 0 {
+    if !opts.Silent {
+      fmt.Printf("OK (%d maintenances, %d hosts)\n", len(maints), len(hosts))
+    }
+    os.Exit(0)
+  }
+  if !opts.Silent {
+    fmt.Println("Validation failed:")
+    for _, p := range problems {
+      fmt.Printf("  - %s\n", p)
+    }
+  }
+  os.Exit(3)
+}
+
+func main() {
+  var opts options
+  
+  // --- parse commant line arguments ---
+  p := flags.NewParser(&opts, flags.Default&^flags.HelpFlag)
+  _, err := p.Parse()
+  if err != nil {
+    fmt.Printf("Fail to parse args: %v", err)
+    os.Exit(3)
+  }
+
+  if opts.Help {
+    p.WriteHelp(os.Stdout)
+    os.Exit(0)
+  }
+
+  if opts.Version {
+    if version == "" {
+      version = "dev"
+    }
+    if commit == "" {
+      commit = "unknown"
+    }
+    if date == "" {
+      date = "unknown"
+    }
+    fmt.Printf("icinga_submitter %s (commit %s, built %s)\n", version, commit, date)
+    os.Exit(0)
+  }
+
+  showURL = opts.ShowURL
+  httpClient = newHTTPClient(opts)
+  onSuccess, onFailure = opts.OnSuccess, opts.OnFailure
+  connMaxAge = opts.ConnMaxAge
+  maxSkew = opts.MaxSkew
+  if opts.OnBadHost != "abort" && opts.OnBadHost != "skip" && opts.OnBadHost != "include" {
+    fmt.Fprintf(os.Stderr, "Invalid --on-bad-host %q, use abort, skip or include\n", opts.OnBadHost)
+    os.Exit(3)
+  }
+  retries, retryBackoffBase, retryJitter = opts.Retries, opts.RetryBackoff, opts.RetryJitter
+  if retryJitter != "none" && retryJitter != "full" && retryJitter != "equal" {
+    fmt.Fprintf(os.Stderr, "Invalid --retry-jitter %q, use none, full or equal\n", retryJitter)
+    os.Exit(3)
+  }
+  httpCachePath = opts.HTTPCache
+  dumpHTTPPath = opts.DumpHTTP
+
+  // --- bound the whole operation ---
+  if opts.Deadline != "" {
+    deadline, err := parseStart(opts.Deadline)
+    if err != nil {
+      fmt.Fprintf(os.Stderr, "Invalid --deadline: %s\n", err.Error())
+      os.Exit(3)
+    }
+    var cancel context.CancelFunc
+    opCtx, cancel = context.WithDeadline(context.Background(), deadline)
+    defer cancel()
+    time.AfterFunc(time.Until(deadline), deadlineExceeded)
+  }
+
+  if opts.PrintConfigSchema {
+    example := iniDefaults
+    fillExample(reflect.ValueOf(&example).Elem(), "")
+    enc := json.NewEncoder(os.Stdout)
+    enc.SetEscapeHTML(false)
+    enc.SetIndent("", "  ")
+    enc.Encode(example)
+
+    // -- key descriptions on stderr, stdout stays valid json --
+    fmt.Fprintln(os.Stderr, "Keys:")
+    t := reflect.TypeOf(example)
+    for i := 0; i < t.NumField(); i++ {
+      fmt.Fprintf(os.Stderr, "  %-20s %s\n", t.Field(i).Tag.Get("json"), t.Field(i).Tag.Get("doc"))
+    }
+    os.Exit(0)
+  }
+
+  // --- get settings from config file ---
+  ini := readINI(opts.ConfigFile)
+
+  // --- syslog, degrade to a warning when unavailable ---
+  if opts.Syslog || ini.Syslog {
+    facility := opts.SyslogFacility
+    if ini.SyslogFacility != "" && facility == "user" {
+      facility = ini.SyslogFacility
+    }
+    if eventLog, err = openSyslog(facility, opts.SyslogPriority); err != nil {
+      fmt.Fprintf(os.Stderr, "Warning: syslog disabled - %s\n", err.Error())
+    }
+  }
+
+  authHeaderName = authHeader(ini)
+  if f := strings.ReplaceAll(ini.AuthHeaderFormat, "%%", ""); f != "" && (strings.Count(f, "%") != 1 || !strings.Contains(f, "%s")) {
+    fmt.Fprintf(os.Stderr, "Invalid AuthHeaderFormat %q in %s: must contain exactly one %%s for the api key\n", ini.AuthHeaderFormat, opts.ConfigFile)
+    os.Exit(3)
+  }
+
+  // --- validate arguments ---
+  opts.Host = resolveHost(normalizeHost(opts.Host, opts), opts, ini)
+  ini.BaseURL, err = selectRegion(opts, ini)
+  if err != nil {
+    fmt.Fprintln(os.Stderr, err.Error())
+    os.Exit(3)
+  }
+  if opts.Host == ""  && ((opts.Enable && !opts.Batch && opts.SpecFile == "") || (opts.GetStatus && opts.HostContains == "" && len(opts.IDs) == 0) || (opts.Exists && len(opts.IDs) == 0) || opts.DisableHost || opts.Refresh) {
+    p.WriteHelp(os.Stdout)
+    os.Exit(3)
+  }
+  if opts.RPD == 0 {
+    for _, env := range []string{"ICINGA_RPD", "CHANGE_NUMBER"} {
+      if v := strings.TrimSpace(os.Getenv(env)); v != "" {
+        rpd, err := strconv.Atoi(v)
+        if err != nil {
+          fmt.Fprintf(os.Stderr, "Invalid RPD in $%s: %q is not a number\n", env, v)
+          os.Exit(3)
+        }
+        opts.RPD = rpd
+        break
+      }
+    }
+  }
+  if opts.BuildURL == "" {
+    opts.BuildURL = ciBuildURL()
+  }
+  if opts.Template != "" {
+    timeoutSet := p.FindOptionByLongName("timeout").IsSet()
+    if err := applyTemplate(opts.Template, &opts, &ini, timeoutSet); err != nil {
+      fmt.Fprintln(os.Stderr, err.Error())
+      os.Exit(3)
+    }
+  }
+  if opts.WindowDuration > 0 {
+    opts.Timeout = opts.WindowDuration.Hours()
+  }
+  if opts.Cron != "" {
+    next, err := nextCron(opts.Cron, time.Now())
+    if err != nil {
+      fmt.Fprintf(os.Stderr, "Invalid --cron: %s\n", err.Error())
+      os.Exit(3)
+    }
+    opts.Start = next.Format(time.RFC3339)
+    dt := getDateTime(next, opts.Timeout)
+    if !opts.Silent {
+      fmt.Fprintf(os.Stderr, "Next cron window: %s - %s\n", dt.startTime, dt.endTime)
+    }
+  }
+  if _, err := parseStart(opts.Start); err != nil {
+    fmt.Fprintf(os.Stderr, "Invalid --start: %s\n", err.Error())
+    os.Exit(3)
+  }
+  if opts.ExtraJSON != "" {
+    if _, err := parseExtraJSON(opts.ExtraJSON); err != nil {
+      fmt.Printf("Invalid --extra-json: %s\n", err.Error())
+      os.Exit(3)
+    }
+  }
+  if opts.Reason != "" {
+    if err := validReason(opts.Reason, ini); err != nil {
+      fmt.Fprintln(os.Stderr, err.Error())
+      os.Exit(3)
+    }
+  }
+  if opts.Refresh && opts.Duration <= 0 {
+    fmt.Fprintln(os.Stderr, "--refresh needs a positive --duration")
+    os.Exit(3)
+  }
+  if opts.RPD < 0 {
+    fmt.Fprintf(os.Stderr, "Invalid RPD %d, must be a positive ticket number\n", opts.RPD)
+    os.Exit(3)
+  }
+  if opts.NoComment && opts.Comment != "" {
+    fmt.Fprintln(os.Stderr, "--no-comment and --comment are mutually exclusive")
+    os.Exit(3)
+  }
+  if opts.FailFast && opts.Continue {
+    fmt.Fprintln(os.Stderr, "--fail-fast and --continue are mutually exclusive")
+    os.Exit(3)
+  }
+  if opts.Output != "text" && opts.Output != "json" && opts.Output != "jsonl" && opts.Output != "csv" && opts.Output != "events" {
+    fmt.Fprintf(os.Stderr, "Invalid --output %q, use one of text, json, jsonl, csv, events\n", opts.Output)
+    os.Exit(3)
+  }
+  if d := opts.CSVDelimiter; d != "tab" && d != "\\t" && (len([]rune(d)) != 1 || d == "\"" || d == "\n" || d == "\r") {
+    fmt.Fprintf(os.Stderr, "Invalid --csv-delimiter %q, use a single character or tab\n", d)
+    os.Exit(3)
+  }
+  if opts.Output == "events" {
+    // -- stdout carries only event lines --
+    emitEvents = true
+    opts.Silent = true
+  }
+  if opts.ServicePattern != "" {
+    if _, err := regexp.Compile(opts.ServicePattern); err != nil {
+      fmt.Fprintf(os.Stderr, "Invalid --service-pattern: %s\n", err.Error())
+      os.Exit(3)
+    }
+  }
+  if opts.DisplayTZ != "" {
+    if _, err := time.LoadLocation(opts.DisplayTZ); err != nil {
+      fmt.Fprintf(os.Stderr, "Invalid --display-timezone: %s\n", err.Error())
+      os.Exit(3)
+    }
+  }
+  if opts.Color != "auto" && opts.Color != "always" && opts.Color != "never" {
+    fmt.Fprintf(os.Stderr, "Invalid --color %q, use one of auto, always, never\n", opts.Color)
+    os.Exit(3)
+  }
+  for _, f := range strings.Split(opts.RedactFields, ",") {
+    switch strings.TrimSpace(f) {
+    case "hosts", "comment", "createdBy", "updatedBy", "author":
+    default:
+      fmt.Fprintf(os.Stderr, "Invalid --redact-fields entry %q\n", f)
+      os.Exit(3)
+    }
+  }
+  if opts.Fields != "" {
+    if _, err := parseFields(opts.Fields); err != nil {
+      fmt.Fprintf(os.Stderr, "Invalid --fields: %s\n", err.Error())
+      os.Exit(3)
+    }
+    if opts.Output != "json" && opts.Output != "jsonl" && opts.Output != "csv" {
+      fmt.Fprintf(os.Stderr, "--fields requires --output json, jsonl or csv, not %s\n", opts.Output)
+      os.Exit(3)
+    }
+  }
+  switch opts.SortBy {
+  case "", "start", "end", "created", "host", "status":
+  default:
+    fmt.Fprintf(os.Stderr, "Invalid --sort-by %q, use one of start, end, created, host, status\n", opts.SortBy)
+    os.Exit(3)
+  }
+  opts.Status = normalizeStatus(opts.Status)
+  if opts.GetStatus && opts.Status != "active" && opts.Status != "completed" && opts.Status != "scheduled" && opts.Status != "deleted" {
+    p.WriteHelp(os.Stdout)
+    os.Exit(3)
+  }
+  if opts.Report {
+    for _, t := range []string{opts.ReportFrom, opts.ReportTo} {
+      if _, err := parseStart(t); err != nil {
+        fmt.Fprintf(os.Stderr, "Invalid report range - %s\n", err.Error())
+        os.Exit(3)
+      }
+    }
+  }
+  if opts.ValidateOnly {
+    maint_validate(opts, ini)
+  }
+  if opts.ProbeEndpoints {
+    maint_probe(opts, ini)
+  }
+  if opts.ListOwners {
+    maint_listOwners(opts, ini)
+  }
+  if !opts.Enable && !opts.Disable && !opts.DisableHost && !opts.GetStatus && !opts.Exists && !opts.Update && !opts.Refresh && !opts.Report {
+    p.WriteHelp(os.Stdout)
+    fmt.Fprintln(os.Stderr, "No action specified, use one of --enable, --disable, --disableall, --update, --refresh, --getstatus, --exists or --report")
+    os.Exit(3)
+  }
+
+  if opts.Enable && opts.Batch {
+    maint_batch(opts, ini)
+  }
+
+  if opts.Enable {
+    maint_enable(opts, ini)
+  }
+  
+  if opts.Disable {
+    maint_disable(opts, ini)
+  }
+
+  if opts.DisableHost {
+    maint_disableHost(opts, ini)
+  }
+
+  if opts.Update {
+    maint_update(opts, ini)
+  }
+
+  if opts.Refresh {
+    maint_refresh(opts, ini)
   }
 
   if opts.GetStatus {
     maint_get(opts, ini)
   }
+
+  if opts.Exists {
+    maint_exists(opts, ini)
+  }
+
+  if opts.Report {
+    maint_report(opts, ini)
+  }
   
   os.Exit(0)
 }
@@ -0,0 +1,310 @@
+package main
+
+import (
+  "encoding/json"
+  "errors"
+  "io/ioutil"
+  "math/rand"
+  "os"
+  "os/exec"
+  "path/filepath"
+  "reflect"
+  "strings"
+  "testing"
+  "time"
+)
+
+// --- mixed case and aliases map to api status values ---
+func TestNormalizeStatus(t *testing.T) {
+  tests := []struct {
+    in   string
+    want string
+  }{
+    {"active", "active"},
+    {"Active", "active"},
+    {" ACTIVE ", "active"},
+    {"Running", "active"},
+    {"DONE", "completed"},
+    {"Completed", "completed"},
+    {"Planned", "scheduled"},
+    {"Cancelled", "deleted"},
+    {"canceled", "deleted"},
+    {"Bogus", "bogus"},
+  }
+  for _, tt := range tests {
+    if got := normalizeStatus(tt.in); got != tt.want {
+      t.Errorf("normalizeStatus(%q) = %q, want %q", tt.in, got, tt.want)
+    }
+  }
+}
+
+// --- comma separated Owners string becomes one payload owner each ---
+func TestOwnersList(t *testing.T) {
+  var ini INI
+  if err := json.Unmarshal([]byte(`{"Owners": "a@x.com, b@x.com"}`), &ini); err != nil {
+    t.Fatal(err)
+  }
+  if len(ini.Owners) != 2 || ini.Owners[0].Name != "a@x.com" || ini.Owners[1].Name != "b@x.com" {
+    t.Fatalf("Owners = %+v, want a@x.com and b@x.com", ini.Owners)
+  }
+
+  owners, err := effectiveOwners(options{}, ini)
+  if err != nil {
+    t.Fatal(err)
+  }
+  payload, _ := json.Marshal(buildMaint("h1", DT{}, owners, options{}, ini))
+  var sent struct {
+    Owners []string `json:"owners"`
+  }
+  json.Unmarshal(payload, &sent)
+  if len(sent.Owners) != 2 || sent.Owners[0] != "a@x.com" || sent.Owners[1] != "b@x.com" {
+    t.Errorf("payload owners = %v, want [a@x.com b@x.com]", sent.Owners)
+  }
+}
+
+// --- structured Owners with roles, filtered by --owner-role ---
+func TestOwnersRoles(t *testing.T) {
+  var ini INI
+  json.Unmarshal([]byte(`{"Owners": [{"name": "a", "role": "primary"}, {"name": "b", "role": "secondary"}]}`), &ini)
+
+  owners, err := effectiveOwners(options{OwnerRole: "secondary"}, ini)
+  if err != nil || len(owners) != 1 || owners[0] != "b" {
+    t.Errorf("effectiveOwners(secondary) = %v, %v, want [b]", owners, err)
+  }
+}
+
+// --- no Owners configured: $USER, or an error with --require-owner ---
+func TestEffectiveOwnersFallback(t *testing.T) {
+  t.Setenv("USER", "alice")
+  owners, err := effectiveOwners(options{}, INI{})
+  if err != nil || len(owners) != 1 || owners[0] != "alice" {
+    t.Errorf("effectiveOwners() = %v, %v, want [alice]", owners, err)
+  }
+
+  if _, err := effectiveOwners(options{RequireOwner: true, ConfigFile: "icinga.json"}, INI{}); err == nil {
+    t.Error("effectiveOwners(--require-owner) succeeded without Owners")
+  }
+
+  t.Setenv("USER", "")
+  if _, err := effectiveOwners(options{}, INI{}); err == nil {
+    t.Error("effectiveOwners() succeeded without Owners and $USER")
+  }
+}
+
+func TestAutoComment(t *testing.T) {
+  if got := autoComment([]string{"alice"}, INI{}); got != "Automatic maintenance mode set by alice" {
+    t.Errorf("autoComment() = %q", got)
+  }
+  if got := autoComment([]string{"a", "b"}, INI{CommentPrefix: "Set by "}); got != "Set by a, b" {
+    t.Errorf("autoComment(CommentPrefix) = %q", got)
+  }
+}
+
+// --- BaseURL with or without trailing slash builds the same urls ---
+func TestBaseURL(t *testing.T) {
+  for _, base := range []string{"https://api.example.com/v1", "https://api.example.com/v1/", "https://api.example.com/v1//"} {
+    ini := INI{BaseURL: normalizeBaseURL(base)}
+    if ini.BaseURL != "https://api.example.com/v1/" {
+      t.Errorf("normalizeBaseURL(%q) = %q", base, ini.BaseURL)
+    }
+    if got := apiURL(ini, "host", "all", "h1"); got != "https://api.example.com/v1/host/all/h1" {
+      t.Errorf("apiURL(%q) = %q", base, got)
+    }
+    if got := apiURL(ini, "/batch/"); got != "https://api.example.com/v1/batch" {
+      t.Errorf("apiURL(%q, /batch/) = %q", base, got)
+    }
+  }
+  if got := normalizeBaseURL(""); got != "" {
+    t.Errorf("normalizeBaseURL(\"\") = %q", got)
+  }
+}
+
+// --- jittered backoff stays within [0,d] (full) and [d/2,d] (equal) ---
+func TestRetryBackoff(t *testing.T) {
+  retryRand = rand.New(rand.NewSource(1))
+  base := time.Second
+
+  for attempt := 0; attempt < 8; attempt++ {
+    d := base << uint(attempt)
+    if d > maxRetryBackoff {
+      d = maxRetryBackoff
+    }
+    if got := retryBackoff(attempt, base, "none"); got != d {
+      t.Errorf("none, attempt %d = %s, want %s", attempt, got, d)
+    }
+    for i := 0; i < 100; i++ {
+      if got := retryBackoff(attempt, base, "full"); got < 0 || got > d {
+        t.Fatalf("full, attempt %d = %s, want 0..%s", attempt, got, d)
+      }
+      if got := retryBackoff(attempt, base, "equal"); got < d/2 || got > d {
+        t.Fatalf("equal, attempt %d = %s, want %s..%s", attempt, got, d/2, d)
+      }
+    }
+  }
+  if got := retryBackoff(3, 0, "full"); got != 0 {
+    t.Errorf("zero base = %s, want 0", got)
+  }
+}
+
+// --- unresolvable host runs --on-failure, maint_enable exits in a child process ---
+func TestDNSFailureRunsHook(t *testing.T) {
+  if out := os.Getenv("ICINGA_TEST_HOOK_OUT"); out != "" {
+    onFailure = "echo \"$ICINGA_ACTION $ICINGA_HOST\" > " + out
+    maint_enable(options{Host: "nonexistent.invalid", DNSTimeout: 5 * time.Second, Silent: true}, INI{})
+    return
+  }
+
+  out := filepath.Join(t.TempDir(), "hook")
+  cmd := exec.Command(os.Args[0], "-test.run=^TestDNSFailureRunsHook$")
+  cmd.Env = append(os.Environ(), "ICINGA_TEST_HOOK_OUT=" + out)
+  err := cmd.Run()
+
+  var exitErr *exec.ExitError
+  if !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+    t.Fatalf("maint_enable exit = %v, want status 3", err)
+  }
+  got, err := ioutil.ReadFile(out)
+  if err != nil {
+    t.Fatalf("failure hook did not run - %s", err.Error())
+  }
+  if strings.TrimSpace(string(got)) != "enable nonexistent.invalid" {
+    t.Errorf("hook saw %q, want \"enable nonexistent.invalid\"", got)
+  }
+}
+
+// --- spec without allservices takes the flag/config default ---
+func TestSpecAllServices(t *testing.T) {
+  dir := t.TempDir()
+  tests := []struct {
+    spec string
+    opts options
+    want bool
+  }{
+    {`{"hosts": ["h1"], "owners": ["a"]}`, options{}, true},
+    {`{"hosts": ["h1"], "owners": ["a"], "allservices": false}`, options{}, false},
+    {`{"hosts": ["h1"], "owners": ["a"]}`, options{NoAllServices: true}, false},
+  }
+  for i, tt := range tests {
+    tt.opts.SpecFile = filepath.Join(dir, "spec.json")
+    ioutil.WriteFile(tt.opts.SpecFile, []byte(tt.spec), 0644)
+    maint, err := readSpecFile(tt.opts, INI{})
+    if err != nil {
+      t.Fatal(err)
+    }
+    if maint.AllServices != tt.want {
+      t.Errorf("case %d: AllServices = %v, want %v", i, maint.AllServices, tt.want)
+    }
+  }
+}
+
+// --- batch entries without allservices take the default ---
+func TestBatchAllServices(t *testing.T) {
+  file := filepath.Join(t.TempDir(), "batch.json")
+  ioutil.WriteFile(file, []byte(`[{"hosts": ["h1"]}, {"hosts": ["h2"], "allservices": false}]`), 0644)
+
+  specs, err := readBatchFile(file, options{}, INI{})
+  if err != nil {
+    t.Fatal(err)
+  }
+  if len(specs) != 2 || !specs[0].AllServices || specs[1].AllServices {
+    t.Errorf("readBatchFile() = %+v, want allservices true then false", specs)
+  }
+}
+
+// --- config example starts from the defaults, every key documented ---
+func TestConfigExample(t *testing.T) {
+  example := iniDefaults
+  fillExample(reflect.ValueOf(&example).Elem(), "")
+  if example.DefaultAllServices == nil || !*example.DefaultAllServices || example.MaxCommentLength != 512 {
+    t.Errorf("example DefaultAllServices/MaxCommentLength not from defaults: %+v", example)
+  }
+  if example.AuthHeaderFormat != "API-KEY %s" {
+    t.Errorf("example AuthHeaderFormat = %q", example.AuthHeaderFormat)
+  }
+
+  typ := reflect.TypeOf(INI{})
+  for i := 0; i < typ.NumField(); i++ {
+    if typ.Field(i).Tag.Get("doc") == "" {
+      t.Errorf("config key %s has no doc tag", typ.Field(i).Name)
+    }
+  }
+}
+
+// --- each chunk caches its id and counts its hosts for --rate-limit ---
+func TestRememberEnable(t *testing.T) {
+  opts := options{IDCache: filepath.Join(t.TempDir(), "cache.json"), RateLimit: 5, RateWindow: time.Hour}
+  rememberEnable([]string{"h1", "h2"}, "id1", opts, INI{})
+  rememberEnable([]string{"h3"}, "id2", opts, INI{})
+
+  cache := loadCache(opts.IDCache)
+  if cache.IDs["h1"] != "id1" || cache.IDs["h3"] != "id2" {
+    t.Errorf("cached ids = %v, want h1=id1 h3=id2", cache.IDs)
+  }
+  for _, host := range []string{"h1", "h2", "h3"} {
+    if len(cache.Enables[host]) != 1 {
+      t.Errorf("enables for %s = %v, want one entry", host, cache.Enables[host])
+    }
+  }
+}
+
+// --- hosts file duplicates collapse after normalizing, first entry wins ---
+func TestDedupeEntries(t *testing.T) {
+  entries := []HOSTENTRY{{Host: "H1", Timeout: 1}, {Host: "h2", Timeout: 2}, {Host: "h1", Timeout: 3}, {Host: "h2"}}
+  got := dedupeEntries(entries, options{})
+  if len(got) != 2 || got[0].Host != "h1" || got[0].Timeout != 1 || got[1].Host != "h2" || got[1].Timeout != 2 {
+    t.Errorf("dedupeEntries() = %+v, want h1 (1h) and h2 (2h)", got)
+  }
+}
+
+// --- repeated --update --reason replaces the comment tag ---
+func TestUpdateReasonTag(t *testing.T) {
+  ini := INI{ReasonInComment: true}
+  opts := options{Reason: "Patch"}
+  current := RESPONSE{Comment: "disk swap", Owners: []string{"a"}}
+  for i := 0; i < 2; i++ {
+    maint, err := requestedUpdate(current, opts, ini)
+    if err != nil {
+      t.Fatal(err)
+    }
+    if maint.Comment != "[patch] disk swap" {
+      t.Fatalf("update %d comment = %q, want \"[patch] disk swap\"", i+1, maint.Comment)
+    }
+    current.Comment = maint.Comment
+  }
+}
+
+// --- cron steps, ranges, dom/dow OR rule, 7 as Sunday, leap days ---
+func TestNextCron(t *testing.T) {
+  from := time.Date(2026, 10, 15, 10, 7, 0, 0, time.UTC) // Thursday
+  tests := []struct {
+    expr string
+    want string
+  }{
+    {"*/15 * * * *", "2026-10-15T10:15:00Z"},
+    {"5,50 * * * *", "2026-10-15T10:50:00Z"},
+    {"0 9-17/4 * * *", "2026-10-15T13:00:00Z"},
+    {"0 8-9 * * *", "2026-10-16T08:00:00Z"},
+    {"30 2 1 * 1", "2026-10-19T02:30:00Z"},
+    {"30 2 16 * 1", "2026-10-16T02:30:00Z"},
+    {"0 0 * * 7", "2026-10-18T00:00:00Z"},
+    {"0 0 * * 0", "2026-10-18T00:00:00Z"},
+    {"0 0 1 1 *", "2027-01-01T00:00:00Z"},
+    {"0 0 29 2 *", "2028-02-29T00:00:00Z"},
+  }
+  for _, tt := range tests {
+    got, err := nextCron(tt.expr, from)
+    if err != nil {
+      t.Errorf("nextCron(%q) failed - %s", tt.expr, err.Error())
+      continue
+    }
+    if got.Format(time.RFC3339) != tt.want {
+      t.Errorf("nextCron(%q) = %s, want %s", tt.expr, got.Format(time.RFC3339), tt.want)
+    }
+  }
+
+  for _, expr := range []string{"* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "0 0 31 2 *"} {
+    if _, err := nextCron(expr, from); err == nil {
+      t.Errorf("nextCron(%q) succeeded, want error", expr)
+    }
+  }
+}
@@ -0,0 +1,13 @@
+//go:build windows || plan9
+// +build windows plan9
+
+package main
+
+import (
+  "fmt"
+)
+
+// --- no syslog on this platform ---
+func openSyslog(facility string, priority string) (eventLogger, error) {
+  return nil, fmt.Errorf("syslog is not available on this platform")
+}
@@ -0,0 +1,66 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package main
+
+import (
+  "fmt"
+  "log/syslog"
+  "strings"
+)
+
+var syslogFacilities = map[string]syslog.Priority {
+  "user":   syslog.LOG_USER,
+  "daemon": syslog.LOG_DAEMON,
+  "auth":   syslog.LOG_AUTH,
+  "local0": syslog.LOG_LOCAL0,
+  "local1": syslog.LOG_LOCAL1,
+  "local2": syslog.LOG_LOCAL2,
+  "local3": syslog.LOG_LOCAL3,
+  "local4": syslog.LOG_LOCAL4,
+  "local5": syslog.LOG_LOCAL5,
+  "local6": syslog.LOG_LOCAL6,
+  "local7": syslog.LOG_LOCAL7,
+}
+
+var syslogSeverities = map[string]syslog.Priority {
+  "info":    syslog.LOG_INFO,
+  "notice":  syslog.LOG_NOTICE,
+  "warning": syslog.LOG_WARNING,
+}
+
+// --- connect to local syslog with facility and severity for successes ---
+func openSyslog(facility string, priority string) (eventLogger, error) {
+  f, ok := syslogFacilities[strings.ToLower(facility)]
+  if !ok {
+    return nil, fmt.Errorf("unknown syslog facility %q", facility)
+  }
+  p, ok := syslogSeverities[strings.ToLower(priority)]
+  if !ok {
+    return nil, fmt.Errorf("unknown syslog priority %q, use info, notice or warning", priority)
+  }
+  w, err := syslog.New(f|p, "icinga_submitter")
+  if err != nil {
+    return nil, err
+  }
+  return syslogLogger{w, p}, nil
+}
+
+type syslogLogger struct {
+  w            *syslog.Writer
+  priority     syslog.Priority
+}
+
+func (l syslogLogger) Success(msg string) error {
+  switch l.priority {
+  case syslog.LOG_NOTICE:
+    return l.w.Notice(msg)
+  case syslog.LOG_WARNING:
+    return l.w.Warning(msg)
+  }
+  return l.w.Info(msg)
+}
+
+func (l syslogLogger) Failure(msg string) error {
+  return l.w.Err(msg)
+}